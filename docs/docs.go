@@ -1,4 +1,5 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
+// Code generated by swaggo/swag. DO NOT EDIT.
+
 package docs
 
 import "github.com/swaggo/swag"
@@ -15,9 +16,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/interactions": {
+        "/admin/dead-letters": {
             "get": {
-                "description": "Get a paginated list of all interactions",
+                "description": "Get a paginated list of interaction events whose counters could not be updated, oldest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,9 +26,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interactions"
+                    "admin"
                 ],
-                "summary": "List all interactions",
+                "summary": "List dead-lettered events",
                 "parameters": [
                     {
                         "type": "integer",
@@ -48,7 +49,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Interaction"
+                                "$ref": "#/definitions/models.DeadLetter"
                             }
                         }
                     },
@@ -59,49 +60,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create a new interaction between a user and a video",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "interactions"
-                ],
-                "summary": "Create a new interaction",
-                "parameters": [
-                    {
-                        "description": "Interaction object",
-                        "name": "interaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.Interaction"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.Interaction"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
             }
         },
-        "/interactions/{id}": {
+        "/admin/dead-letters/{id}": {
             "get": {
-                "description": "Get details of a specific interaction",
+                "description": "Get the event, last error and number of attempts of a dead-lettered event",
                 "consumes": [
                     "application/json"
                 ],
@@ -109,13 +72,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interactions"
+                    "admin"
                 ],
-                "summary": "Get an interaction by ID",
+                "summary": "Get a dead-lettered event by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Interaction ID",
+                        "description": "Dead letter ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -125,25 +88,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.Interaction"
+                            "$ref": "#/definitions/models.DeadLetter"
                         }
                     },
                     "400": {
-                        "description": "Invalid interaction ID",
+                        "description": "Invalid dead letter ID",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Interaction not found",
+                        "description": "Dead letter not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing interaction",
+            "delete": {
+                "description": "Delete a dead-lettered event so it is never applied",
                 "consumes": [
                     "application/json"
                 ],
@@ -151,36 +114,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interactions"
+                    "admin"
                 ],
-                "summary": "Update an interaction",
+                "summary": "Discard a dead-lettered event",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Interaction ID",
+                        "description": "Dead letter ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated interaction object",
-                        "name": "interaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.Interaction"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/request.Interaction"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid interaction ID or data",
+                        "description": "Invalid dead letter ID",
                         "schema": {
                             "type": "string"
                         }
@@ -192,9 +143,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an existing interaction",
+            }
+        },
+        "/admin/dead-letters/{id}/replay": {
+            "post": {
+                "description": "Enqueue a dead-lettered event again and remove it from the dead letters",
                 "consumes": [
                     "application/json"
                 ],
@@ -202,13 +155,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interactions"
+                    "admin"
                 ],
-                "summary": "Delete an interaction",
+                "summary": "Replay a dead-lettered event",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Interaction ID",
+                        "description": "Dead letter ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -219,7 +172,13 @@ const docTemplate = `{
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid interaction ID",
+                        "description": "Invalid dead letter ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
                         "schema": {
                             "type": "string"
                         }
@@ -229,13 +188,19 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "503": {
+                        "description": "Interaction queue is full",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/users": {
+        "/admin/experiments": {
             "get": {
-                "description": "Get a paginated list of all users",
+                "description": "Get a paginated list of experiments, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -243,9 +208,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "List all users",
+                "summary": "List all experiments",
                 "parameters": [
                     {
                         "type": "integer",
@@ -266,7 +231,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/request.User"
+                                "$ref": "#/definitions/models.Experiment"
                             }
                         }
                     },
@@ -279,7 +244,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new user in the system",
+                "description": "Create a stopped experiment whose arms each rank videos with a scoring strategy and receive a weighted share of users",
                 "consumes": [
                     "application/json"
                 ],
@@ -287,17 +252,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Create a new user",
+                "summary": "Create an experiment",
                 "parameters": [
                     {
-                        "description": "User object",
-                        "name": "user",
+                        "description": "Experiment object",
+                        "name": "experiment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.User"
+                            "$ref": "#/definitions/request.Experiment"
                         }
                     }
                 ],
@@ -305,7 +270,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.User"
+                            "$ref": "#/definitions/models.Experiment"
                         }
                     },
                     "400": {
@@ -323,9 +288,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/admin/experiments/{id}": {
             "get": {
-                "description": "Get details of a specific user",
+                "description": "Get details of a specific experiment and its arms",
                 "consumes": [
                     "application/json"
                 ],
@@ -333,13 +298,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get a user by ID",
+                "summary": "Get an experiment by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Experiment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -349,25 +314,27 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.User"
+                            "$ref": "#/definitions/models.Experiment"
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID",
+                        "description": "Invalid experiment ID",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Experiment not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing user's information",
+            }
+        },
+        "/admin/experiments/{id}/results": {
+            "get": {
+                "description": "Get the impressions and engagement counted for each arm of an experiment",
                 "consumes": [
                     "application/json"
                 ],
@@ -375,36 +342,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Update a user",
+                "summary": "Get experiment results",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Experiment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated user object",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.UserUpdate"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.ArmResult"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID or data",
+                        "description": "Invalid experiment ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Experiment not found",
                         "schema": {
                             "type": "string"
                         }
@@ -416,9 +383,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an existing user",
+            }
+        },
+        "/admin/experiments/{id}/start": {
+            "post": {
+                "description": "Score every video for each arm and make the experiment the only running one",
                 "consumes": [
                     "application/json"
                 ],
@@ -426,13 +395,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Delete a user",
+                "summary": "Start an experiment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Experiment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -443,7 +412,13 @@ const docTemplate = `{
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid user ID",
+                        "description": "Invalid experiment ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Experiment not found",
                         "schema": {
                             "type": "string"
                         }
@@ -457,9 +432,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{userID}/videos/{videoID}/interactions": {
-            "get": {
-                "description": "Get all interactions for a specific user and video combination",
+        "/admin/experiments/{id}/stop": {
+            "post": {
+                "description": "Stop an experiment so every user is served the default leaderboard again",
                 "consumes": [
                     "application/json"
                 ],
@@ -467,37 +442,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interactions"
+                    "admin"
                 ],
-                "summary": "Get all interactions between a user and a video",
+                "summary": "Stop an experiment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Video ID",
-                        "name": "videoID",
+                        "description": "Experiment ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid experiment ID",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Interaction"
-                            }
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Invalid user or video ID",
+                    "404": {
+                        "description": "Experiment not found",
                         "schema": {
                             "type": "string"
                         }
@@ -511,9 +479,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{user_id}/viewed/top-videos": {
+        "/admin/queue/stats": {
             "get": {
-                "description": "Get the top N highest-scoring videos that a specific user has viewed",
+                "description": "Get the queue depth, the outbox events not relayed to the queue yet, their capacity and how many events of each type were admitted or shed",
                 "consumes": [
                     "application/json"
                 ],
@@ -521,38 +489,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
-                ],
-                "summary": "Get top viewed videos by user",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit the number of results (default 10)",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "admin"
                 ],
+                "summary": "Get interaction queue stats",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Video"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid user ID",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/services.QueueStats"
                         }
                     },
                     "500": {
@@ -564,9 +508,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos": {
+        "/admin/ranking/overrides": {
             "get": {
-                "description": "Get a paginated list of all videos",
+                "description": "Get a paginated list of ranking overrides, including expired ones",
                 "consumes": [
                     "application/json"
                 ],
@@ -574,9 +518,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "List all videos",
+                "summary": "List all ranking overrides",
                 "parameters": [
                     {
                         "type": "integer",
@@ -597,7 +541,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/request.Video"
+                                "$ref": "#/definitions/models.RankingOverride"
                             }
                         }
                     },
@@ -610,7 +554,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new video in the system",
+                "description": "Pin a video at a position, boost or demote its score, or blacklist it from trending",
                 "consumes": [
                     "application/json"
                 ],
@@ -618,17 +562,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Create a new video",
+                "summary": "Create a ranking override",
                 "parameters": [
                     {
-                        "description": "Video object",
-                        "name": "video",
+                        "description": "Override object",
+                        "name": "override",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.Video"
+                            "$ref": "#/definitions/request.RankingOverride"
                         }
                     }
                 ],
@@ -636,7 +580,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.Video"
+                            "$ref": "#/definitions/models.RankingOverride"
                         }
                     },
                     "400": {
@@ -645,6 +589,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -654,9 +604,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/{id}": {
+        "/admin/ranking/overrides/{id}": {
             "get": {
-                "description": "Get details of a specific video",
+                "description": "Get details of a specific ranking override",
                 "consumes": [
                     "application/json"
                 ],
@@ -664,13 +614,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Get a video by ID",
+                "summary": "Get a ranking override by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Video ID",
+                        "description": "Override ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -680,25 +630,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.Video"
+                            "$ref": "#/definitions/models.RankingOverride"
                         }
                     },
                     "400": {
-                        "description": "Invalid video ID",
+                        "description": "Invalid override ID",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Video not found",
+                        "description": "Override not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing video's information",
+            "delete": {
+                "description": "Delete a ranking override so the video is ranked by its raw score again",
                 "consumes": [
                     "application/json"
                 ],
@@ -706,36 +656,73 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Update a video",
+                "summary": "Delete a ranking override",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Video ID",
+                        "description": "Override ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated video object",
-                        "name": "video",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.Video"
-                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid override ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/ranking/rebuild": {
+            "post": {
+                "description": "Repopulate the all-time leaderboard from the scores stored with the videos when it is missing or incomplete, for instance after Redis lost its data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Rebuild the leaderboard from the database",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Rebuild even when the leaderboard looks complete",
+                        "name": "force",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/request.Video"
+                            "$ref": "#/definitions/services.RebuildResult"
                         }
                     },
                     "400": {
-                        "description": "Invalid video ID or data",
+                        "description": "Invalid force",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "A rebuild is already running",
                         "schema": {
                             "type": "string"
                         }
@@ -747,9 +734,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an existing video",
+            }
+        },
+        "/admin/ranking/replay": {
+            "post": {
+                "description": "Sum the logged interaction events since a point in time, score them with the chosen scorer and atomically swap in the all-time and creator leaderboards built from them. Only the leaderboards are replayed: the scores and counters stored with the videos are left unchanged, and the next event of a video rescores it with the configured scorer",
                 "consumes": [
                     "application/json"
                 ],
@@ -757,24 +746,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Delete a video",
+                "summary": "Replay the interaction log into the leaderboard",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Video ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Replay options; an empty from replays the whole log and an empty scorer uses the configured one",
+                        "name": "replay",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.Replay"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ReplayResult"
+                        }
                     },
                     "400": {
-                        "description": "Invalid video ID",
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "A rebuild is already running",
                         "schema": {
                             "type": "string"
                         }
@@ -788,9 +788,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/{id}/comments": {
-            "patch": {
-                "description": "Change the number of comments for a specific video",
+        "/admin/reconcile": {
+            "post": {
+                "description": "Recount the counters of every video from the interactions table, repair drifted counters and scores and remove leaderboard members of deleted videos. Videos with recent or dead-lettered events are skipped.",
                 "consumes": [
                     "application/json"
                 ],
@@ -798,34 +798,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Change comments amount",
+                "summary": "Reconcile video counters and leaderboards",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Video ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Step",
-                        "name": "step",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Only report the drift without repairing it",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Comments amount changed",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/services.DriftReport"
                         }
                     },
                     "400": {
-                        "description": "Invalid video ID or step",
+                        "description": "Invalid dry_run",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "A reconciliation is already running",
                         "schema": {
                             "type": "string"
                         }
@@ -839,9 +837,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/{id}/likes": {
-            "patch": {
-                "description": "Change the number of likes for a specific video",
+        "/admin/reconcile/report": {
+            "get": {
+                "description": "Get what the last reconciliation, scheduled or requested, found and repaired",
                 "consumes": [
                     "application/json"
                 ],
@@ -849,34 +847,70 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "admin"
                 ],
-                "summary": "Change likes amount",
+                "summary": "Get the last drift report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.DriftReport"
+                        }
+                    },
+                    "404": {
+                        "description": "No reconciliation has run yet",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/creators/trending": {
+            "get": {
+                "description": "Get the creators whose videos have the highest total score",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "creators"
+                ],
+                "summary": "Get trending creators",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Video ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Limit the number of results (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Step",
-                        "name": "step",
-                        "in": "query",
-                        "required": true
+                        "description": "Number of entries to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Likes amount changed",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.TrendingCreator"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid video ID or step",
+                        "description": "Invalid limit or offset parameter",
                         "schema": {
                             "type": "string"
                         }
@@ -890,9 +924,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/{id}/views": {
-            "patch": {
-                "description": "Change the number of views for a specific video",
+        "/interactions": {
+            "get": {
+                "description": "Get a paginated list of all interactions",
                 "consumes": [
                     "application/json"
                 ],
@@ -900,34 +934,97 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "videos"
+                    "interactions"
                 ],
-                "summary": "Change views amount",
+                "summary": "List all interactions",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Video ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Step",
-                        "name": "step",
-                        "in": "query",
-                        "required": true
+                        "description": "Number of items per page",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Views amount changed",
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Interaction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new interaction between a user and a video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interactions"
+                ],
+                "summary": "Create a new interaction",
+                "parameters": [
+                    {
+                        "description": "Interaction object",
+                        "name": "interaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.Interaction"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client-generated key; retries with the same key return the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Interaction"
+                        }
                     },
                     "400": {
-                        "description": "Invalid video ID or step",
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User or video not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key was used with a different request",
                         "schema": {
                             "type": "string"
                         }
@@ -937,52 +1034,1870 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "503": {
+                        "description": "Interaction queue is full, retry after the Retry-After header",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Interaction": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "type": {
+        },
+        "/interactions/{id}": {
+            "get": {
+                "description": "Get details of a specific interaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interactions"
+                ],
+                "summary": "Get an interaction by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Interaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.Interaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid interaction ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Interaction not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing interaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interactions"
+                ],
+                "summary": "Update an interaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Interaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated interaction object",
+                        "name": "interaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.Interaction"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.Interaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid interaction ID or data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing interaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interactions"
+                ],
+                "summary": "Delete an interaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Interaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid interaction ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Interaction queue is full, retry after the Retry-After header",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rising": {
+            "get": {
+                "description": "Get videos whose recent engagement rate is growing fastest relative to their own baseline, without blacklisted videos and with boosts and demotions applied",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Get rising videos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit the number of results (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.RisingVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or offset parameter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trending": {
+            "get": {
+                "description": "Get a page of the all-time, hot, rolling-window, category or tag leaderboard with video details, score and rank, with editorial overrides applied",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Get trending videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Leaderboard window: all (default), hot, 1h, 24h or 7d",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restrict the all-time leaderboard to a category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restrict the all-time leaderboard to a tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Serve the all-time leaderboard of the experiment arm the user is assigned to",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit the number of results (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.TrendingVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid window, category, tag, user_id, limit or offset parameter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trending/history": {
+            "get": {
+                "description": "Get the most recent leaderboard snapshot taken at or before the given time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Get historical leaderboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC3339 timestamp (default now)",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LeaderboardSnapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid at parameter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trending/hot": {
+            "get": {
+                "description": "Get a page of the videos with the highest time-decayed engagement score, with editorial overrides applied. Alias of GET /trending?window=hot",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Get hot videos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit the number of results (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.TrendingVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or offset parameter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trending/windows/{window}": {
+            "get": {
+                "description": "Get a page of the videos with the highest engagement during the last hour (1h), day (24h) or week (7d), with editorial overrides applied. Alias of GET /trending?window={window}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Get trending videos within a window",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Window (1h, 24h or 7d)",
+                        "name": "window",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit the number of results (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.TrendingVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid window, limit or offset parameter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Get a paginated list of all users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List all users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/request.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new user in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User object",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Get details of a specific user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing user's information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated user object",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID or data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/videos": {
+            "get": {
+                "description": "Get a paginated list of the videos created by a user, highest score first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "creators"
+                ],
+                "summary": "Get videos of a creator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Video"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userID}/videos/{videoID}/interactions": {
+            "get": {
+                "description": "Get all interactions for a specific user and video combination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interactions"
+                ],
+                "summary": "Get all interactions between a user and a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "videoID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Interaction"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user or video ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/viewed/top-videos": {
+            "get": {
+                "description": "Get the top N highest-scoring videos that a specific user has viewed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Get top viewed videos by user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit the number of results (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Video"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos": {
+            "get": {
+                "description": "Get a paginated list of all videos",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "List all videos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/request.Video"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new video in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Create a new video",
+                "parameters": [
+                    {
+                        "description": "Video object",
+                        "name": "video",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.Video"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.Video"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}": {
+            "get": {
+                "description": "Get details of a specific video with its categories and tags",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Get a video by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.Video"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing video's information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Update a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated video object",
+                        "name": "video",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.Video"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/request.Video"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID or data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Delete a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid video ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/comments": {
+            "patch": {
+                "description": "Change the number of comments for a specific video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Change comments amount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Step",
+                        "name": "step",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Comments amount changed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID or step",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/likes": {
+            "patch": {
+                "description": "Change the number of likes for a specific video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Change likes amount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Step",
+                        "name": "step",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Likes amount changed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID or step",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/rank-history": {
+            "get": {
+                "description": "Get every leaderboard snapshot entry of a video between from and to",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Get rank history of a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start time (default 7 days ago)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 end time (default now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LeaderboardSnapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID, from or to parameter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/ranking/explain": {
+            "get": {
+                "description": "Get the scoring strategy, counters, weights, decay, overrides and resulting score and rank of a video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "Explain the ranking of a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.RankingExplanation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/views": {
+            "patch": {
+                "description": "Change the number of views for a specific video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "videos"
+                ],
+                "summary": "Change views amount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Step",
+                        "name": "step",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Views amount changed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid video ID or step",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "step": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.InteractionType"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Experiment": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "arms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperimentArm"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "stopped_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExperimentArm": {
+            "type": "object",
+            "properties": {
+                "experiment_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scorer": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Interaction": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "type": {
                     "$ref": "#/definitions/models.InteractionType"
                 },
-                "updated_at": {
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.InteractionType": {
+            "type": "string",
+            "enum": [
+                "like",
+                "view",
+                "comment"
+            ],
+            "x-enum-varnames": [
+                "Like",
+                "View",
+                "Comment"
+            ]
+        },
+        "models.LeaderboardSnapshot": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "taken_at": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OverrideAction": {
+            "type": "string",
+            "enum": [
+                "pin",
+                "boost",
+                "demote",
+                "blacklist"
+            ],
+            "x-enum-varnames": [
+                "Pin",
+                "Boost",
+                "Demote",
+                "Blacklist"
+            ]
+        },
+        "models.RankingOverride": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.OverrideAction"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "multiplier": {
+                    "type": "number"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "$ref": "#/definitions/models.TagKind"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TagKind": {
+            "type": "string",
+            "enum": [
+                "category",
+                "tag"
+            ],
+            "x-enum-varnames": [
+                "CategoryTag",
+                "KeywordTag"
+            ]
+        },
+        "models.Video": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "likes": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
+        },
+        "request.Experiment": {
+            "type": "object",
+            "required": [
+                "arms",
+                "name"
+            ],
+            "properties": {
+                "arms": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/request.ExperimentArm"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3
+                }
+            }
+        },
+        "request.ExperimentArm": {
+            "type": "object",
+            "required": [
+                "name",
+                "scorer",
+                "weight"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1
+                },
+                "scorer": {
+                    "type": "string",
+                    "enum": [
+                        "linear",
+                        "log",
+                        "wilson",
+                        "bayesian"
+                    ]
+                },
+                "weight": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                }
+            }
+        },
+        "request.Interaction": {
+            "type": "object",
+            "required": [
+                "type",
+                "user_id",
+                "video_id"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "type": {
+                    "enum": [
+                        "like",
+                        "comment",
+                        "view"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.InteractionType"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.RankingOverride": {
+            "type": "object",
+            "required": [
+                "action",
+                "video_id"
+            ],
+            "properties": {
+                "action": {
+                    "enum": [
+                        "pin",
+                        "boost",
+                        "demote",
+                        "blacklist"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OverrideAction"
+                        }
+                    ]
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "multiplier": {
+                    "type": "number"
+                },
+                "position": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.Replay": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "scorer": {
+                    "type": "string",
+                    "enum": [
+                        "linear",
+                        "log",
+                        "wilson",
+                        "bayesian"
+                    ]
+                }
+            }
+        },
+        "request.User": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                }
+            }
+        },
+        "request.UserUpdate": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "request.Video": {
+            "type": "object",
+            "required": [
+                "create_by",
+                "title"
+            ],
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "comments": {
+                    "type": "integer"
+                },
+                "create_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "likes": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.RisingVideo": {
+            "type": "object",
+            "properties": {
+                "baseline_rate": {
+                    "type": "number"
+                },
+                "comments": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "user_id": {
+                "description": {
                     "type": "string"
                 },
-                "video_id": {
+                "id": {
+                    "type": "string"
+                },
+                "likes": {
+                    "type": "integer"
+                },
+                "pinned": {
+                    "description": "Pinned is set when an editorial override holds the video at its rank",
+                    "type": "boolean"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "recent_rate": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "total_score": {
+                    "type": "number"
+                },
+                "user_id": {
                     "type": "string"
+                },
+                "views": {
+                    "type": "integer"
                 }
             }
         },
-        "models.InteractionType": {
-            "type": "string",
-            "enum": [
-                "like",
-                "view",
-                "comment"
-            ],
-            "x-enum-varnames": [
-                "Like",
-                "View",
-                "Comment"
-            ]
+        "response.TrendingCreator": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
         },
-        "models.Video": {
+        "response.TrendingVideo": {
             "type": "object",
             "properties": {
                 "comments": {
@@ -1000,14 +2915,21 @@ const docTemplate = `{
                 "likes": {
                     "type": "integer"
                 },
+                "pinned": {
+                    "description": "Pinned is set when an editorial override holds the video at its rank",
+                    "type": "boolean"
+                },
+                "rank": {
+                    "type": "integer"
+                },
                 "score": {
                     "type": "number"
                 },
                 "title": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "total_score": {
+                    "type": "number"
                 },
                 "user_id": {
                     "type": "string"
@@ -1017,101 +2939,273 @@ const docTemplate = `{
                 }
             }
         },
-        "request.Interaction": {
+        "services.ArmResult": {
             "type": "object",
-            "required": [
-                "type",
-                "user_id",
-                "video_id"
-            ],
             "properties": {
-                "content": {
-                    "type": "string",
-                    "maxLength": 1000
+                "arm": {
+                    "$ref": "#/definitions/models.ExperimentArm"
                 },
-                "type": {
-                    "enum": [
-                        "like",
-                        "comment",
-                        "view"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.InteractionType"
-                        }
-                    ]
+                "counters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "services.CounterDrift": {
+            "type": "object",
+            "properties": {
+                "counted": {
+                    "$ref": "#/definitions/services.VideoCounters"
                 },
-                "user_id": {
-                    "type": "string"
+                "stored": {
+                    "$ref": "#/definitions/services.VideoCounters"
                 },
                 "video_id": {
                     "type": "string"
                 }
             }
         },
-        "request.User": {
+        "services.DriftReport": {
             "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "email": {
+                "counter_drifts": {
+                    "description": "CounterDrifts counts the videos whose counters differ from their interactions",
+                    "type": "integer"
+                },
+                "creator_drifts": {
+                    "description": "CreatorDrifts counts the creators whose leaderboard score differs from the sum of the scores of their videos",
+                    "type": "integer"
+                },
+                "drifts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.CounterDrift"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "finished_at": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "missing_members": {
+                    "description": "MissingMembers counts the videos with interactions that are missing from the leaderboard",
+                    "type": "integer"
                 },
-                "username": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3
+                "orphan_members": {
+                    "description": "OrphanMembers counts the leaderboard members whose video no longer exists",
+                    "type": "integer"
+                },
+                "orphans": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "repaired": {
+                    "description": "Repaired counts the videos and orphans fixed, always 0 on a dry run",
+                    "type": "integer"
+                },
+                "score_drifts": {
+                    "description": "ScoreDrifts counts the videos whose stored or leaderboard score differs from the score of their counters",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Skipped counts the videos left alone because events of theirs are still in flight or dead-lettered",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "videos_checked": {
+                    "description": "VideosChecked counts the videos recounted",
+                    "type": "integer"
                 }
             }
         },
-        "request.UserUpdate": {
+        "services.HotExplanation": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "age_hours": {
+                    "type": "number"
+                },
+                "engagement": {
+                    "type": "number"
+                },
+                "gravity": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "services.QueueStats": {
+            "type": "object",
+            "properties": {
+                "admitted": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "outbox_backlog": {
+                    "type": "integer"
+                },
+                "shed": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "view_limit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.RankingExplanation": {
+            "type": "object",
+            "properties": {
+                "blacklisted": {
+                    "type": "boolean"
+                },
+                "decay": {
+                    "$ref": "#/definitions/services.HotExplanation"
+                },
+                "final_score": {
+                    "type": "number"
+                },
+                "multiplier": {
+                    "type": "number"
+                },
+                "overrides": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RankingOverride"
+                    }
+                },
+                "pinned_at": {
+                    "type": "integer"
+                },
+                "raw_rank": {
+                    "type": "integer"
+                },
+                "scoring": {
+                    "$ref": "#/definitions/services.ScoreExplanation"
+                },
+                "stored_score": {
+                    "type": "number"
+                },
+                "trending_rank": {
+                    "type": "integer"
+                },
+                "video_id": {
                     "type": "string"
+                }
+            }
+        },
+        "services.RebuildResult": {
+            "type": "object",
+            "properties": {
+                "creator_members": {
+                    "description": "CreatorMembers is how many creators the creator leaderboard held before the rebuild",
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "creators": {
+                    "description": "Creators is how many creators were written to the rebuilt creator leaderboard",
+                    "type": "integer"
+                },
+                "members": {
+                    "description": "Members is how many videos the leaderboard held before the rebuild",
+                    "type": "integer"
+                },
+                "rebuilt": {
+                    "description": "Rebuilt is false when the leaderboards were complete and the rebuild was not forced",
+                    "type": "boolean"
+                },
+                "scored": {
+                    "description": "Scored is how many videos have a stored score",
+                    "type": "integer"
+                },
+                "videos": {
+                    "description": "Videos is how many videos were written to the rebuilt leaderboard",
+                    "type": "integer"
                 }
             }
         },
-        "request.Video": {
+        "services.ReplayResult": {
             "type": "object",
-            "required": [
-                "create_by",
-                "title"
-            ],
             "properties": {
-                "comments": {
+                "from": {
+                    "type": "string"
+                },
+                "scorer": {
+                    "type": "string"
+                },
+                "videos": {
                     "type": "integer"
+                }
+            }
+        },
+        "services.ScoreComponent": {
+            "type": "object",
+            "properties": {
+                "contribution": {
+                    "type": "number"
                 },
-                "create_by": {
+                "input": {
                     "type": "string"
                 },
-                "description": {
+                "transform": {
                     "type": "string"
                 },
-                "likes": {
+                "value": {
                     "type": "integer"
                 },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "services.ScoreExplanation": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ScoreComponent"
+                    }
+                },
+                "parameters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
                 "score": {
                     "type": "number"
                 },
-                "title": {
-                    "type": "string",
-                    "minLength": 3
+                "strategy": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.VideoCounters": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "integer"
+                },
+                "likes": {
+                    "type": "integer"
                 },
                 "views": {
                     "type": "integer"
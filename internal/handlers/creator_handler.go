@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/services"
+)
+
+// CreatorHandler handles HTTP requests for the creator leaderboard
+// @title Creator API
+// @description API for ranking video creators
+type CreatorHandler struct {
+	creatorService *services.CreatorService
+	userService    *services.UserService
+}
+
+// NewCreatorHandler creates a new creator handler
+func NewCreatorHandler(creatorService *services.CreatorService, userService *services.UserService) *CreatorHandler {
+	return &CreatorHandler{
+		creatorService: creatorService,
+		userService:    userService,
+	}
+}
+
+// GetTrendingCreators handles retrieving the creator leaderboard
+// @Summary Get trending creators
+// @Description Get the creators whose videos have the highest total score
+// @Tags creators
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit the number of results (default 10, max 100)"
+// @Param offset query int false "Number of entries to skip (default 0)"
+// @Success 200 {array} response.TrendingCreator
+// @Failure 400 {string} string "Invalid limit or offset parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /creators/trending [get]
+func (h *CreatorHandler) GetTrendingCreators(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 10 // Default limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 100 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	creators, err := h.creatorService.GetTrendingCreators(r.Context(), offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(creators)
+}
+
+// GetCreatorVideos handles retrieving the videos of a creator ordered by score
+// @Summary Get videos of a creator
+// @Description Get a paginated list of the videos created by a user, highest score first
+// @Tags creators
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Number of items per page"
+// @Success 200 {array} models.Video
+// @Failure 400 {string} string "Invalid user ID"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/videos [get]
+func (h *CreatorHandler) GetCreatorVideos(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.userService.GetUser(id); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	videos, err := h.creatorService.GetCreatorVideos(id, page, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(videos)
+}
+
+// RegisterRoutes registers the creator routes
+func (h *CreatorHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/creators/trending", h.GetTrendingCreators).Methods("GET")
+	r.HandleFunc("/users/{id}/videos", h.GetCreatorVideos).Methods("GET")
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/services"
+	"gorm.io/gorm"
+)
+
+// DeadLetterHandler handles HTTP requests for dead-lettered interaction events
+// @title Dead Letter API
+// @description Admin API for inspecting, replaying and discarding interaction events that exhausted their retries
+type DeadLetterHandler struct {
+	deadLetterService *services.DeadLetterService
+}
+
+// NewDeadLetterHandler creates a new dead letter handler
+func NewDeadLetterHandler(deadLetterService *services.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// ListDeadLetters handles retrieving a list of dead-lettered events with pagination
+// @Summary List dead-lettered events
+// @Description Get a paginated list of interaction events whose counters could not be updated, oldest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Number of items per page"
+// @Success 200 {array} models.DeadLetter
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/dead-letters [get]
+func (h *DeadLetterHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	deadLetters, err := h.deadLetterService.ListDeadLetters(page, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deadLetters)
+}
+
+// GetDeadLetter handles retrieving a dead-lettered event by ID
+// @Summary Get a dead-lettered event by ID
+// @Description Get the event, last error and number of attempts of a dead-lettered event
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} models.DeadLetter
+// @Failure 400 {string} string "Invalid dead letter ID"
+// @Failure 404 {string} string "Dead letter not found"
+// @Router /admin/dead-letters/{id} [get]
+func (h *DeadLetterHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	deadLetter, err := h.deadLetterService.GetDeadLetter(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deadLetter)
+}
+
+// ReplayDeadLetter handles putting a dead-lettered event back on the queue
+// @Summary Replay a dead-lettered event
+// @Description Enqueue a dead-lettered event again and remove it from the dead letters
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Dead letter ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid dead letter ID"
+// @Failure 404 {string} string "Dead letter not found"
+// @Failure 500 {string} string "Internal server error"
+// @Failure 503 {string} string "Interaction queue is full"
+// @Router /admin/dead-letters/{id}/replay [post]
+func (h *DeadLetterHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.deadLetterService.ReplayDeadLetter(r.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Dead letter not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrQueueFull) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DiscardDeadLetter handles removing a dead-lettered event without applying it
+// @Summary Discard a dead-lettered event
+// @Description Delete a dead-lettered event so it is never applied
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Dead letter ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid dead letter ID"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/dead-letters/{id} [delete]
+func (h *DeadLetterHandler) DiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.deadLetterService.DiscardDeadLetter(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes registers the dead letter routes
+func (h *DeadLetterHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/admin/dead-letters", h.ListDeadLetters).Methods("GET")
+	r.HandleFunc("/admin/dead-letters/{id}", h.GetDeadLetter).Methods("GET")
+	r.HandleFunc("/admin/dead-letters/{id}/replay", h.ReplayDeadLetter).Methods("POST")
+	r.HandleFunc("/admin/dead-letters/{id}", h.DiscardDeadLetter).Methods("DELETE")
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/params/request"
+	"github.com/trieuvy/video-ranking/internal/services"
+	"gorm.io/gorm"
+)
+
+// ExperimentHandler handles HTTP requests for ranking experiments
+// @title Experiment API
+// @description Admin API for A/B testing scoring strategies
+type ExperimentHandler struct {
+	experimentService *services.ExperimentService
+}
+
+// NewExperimentHandler creates a new experiment handler
+func NewExperimentHandler(experimentService *services.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+	}
+}
+
+// CreateExperiment handles the creation of a new experiment
+// @Summary Create an experiment
+// @Description Create a stopped experiment whose arms each rank videos with a scoring strategy and receive a weighted share of users
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param experiment body request.Experiment true "Experiment object"
+// @Success 200 {object} models.Experiment
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/experiments [post]
+func (h *ExperimentHandler) CreateExperiment(w http.ResponseWriter, r *http.Request) {
+	var experiment request.Experiment
+	if err := json.NewDecoder(r.Body).Decode(&experiment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var validate = validator.New()
+	err := validate.Struct(experiment)
+	if err != nil {
+		var sb strings.Builder
+		for _, e := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field '%s' failed on the '%s' rule\n", e.Field(), e.Tag()))
+		}
+		http.Error(w, sb.String(), http.StatusBadRequest)
+		return
+	}
+	experimentModel := models.Experiment{
+		Name: experiment.Name,
+	}
+	for _, arm := range experiment.Arms {
+		experimentModel.Arms = append(experimentModel.Arms, models.ExperimentArm{
+			Name:   arm.Name,
+			Scorer: arm.Scorer,
+			Weight: arm.Weight,
+		})
+	}
+	if err := h.experimentService.CreateExperiment(&experimentModel); err != nil {
+		if errors.Is(err, services.ErrInvalidExperiment) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(experimentModel)
+}
+
+// GetExperiment handles retrieving an experiment by ID
+// @Summary Get an experiment by ID
+// @Description Get details of a specific experiment and its arms
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Experiment ID"
+// @Success 200 {object} models.Experiment
+// @Failure 400 {string} string "Invalid experiment ID"
+// @Failure 404 {string} string "Experiment not found"
+// @Router /admin/experiments/{id} [get]
+func (h *ExperimentHandler) GetExperiment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid experiment ID", http.StatusBadRequest)
+		return
+	}
+
+	experiment, err := h.experimentService.GetExperiment(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(experiment)
+}
+
+// ListExperiments handles retrieving a list of experiments with pagination
+// @Summary List all experiments
+// @Description Get a paginated list of experiments, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Number of items per page"
+// @Success 200 {array} models.Experiment
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/experiments [get]
+func (h *ExperimentHandler) ListExperiments(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	experiments, err := h.experimentService.ListExperiments(page, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(experiments)
+}
+
+// StartExperiment handles starting an experiment
+// @Summary Start an experiment
+// @Description Score every video for each arm and make the experiment the only running one
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Experiment ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid experiment ID"
+// @Failure 404 {string} string "Experiment not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/experiments/{id}/start [post]
+func (h *ExperimentHandler) StartExperiment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid experiment ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.experimentService.StartExperiment(r.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StopExperiment handles stopping an experiment
+// @Summary Stop an experiment
+// @Description Stop an experiment so every user is served the default leaderboard again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Experiment ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid experiment ID"
+// @Failure 404 {string} string "Experiment not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/experiments/{id}/stop [post]
+func (h *ExperimentHandler) StopExperiment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid experiment ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.experimentService.StopExperiment(r.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetExperimentResults handles retrieving the evaluation counters of an experiment
+// @Summary Get experiment results
+// @Description Get the impressions and engagement counted for each arm of an experiment
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Experiment ID"
+// @Success 200 {array} services.ArmResult
+// @Failure 400 {string} string "Invalid experiment ID"
+// @Failure 404 {string} string "Experiment not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/experiments/{id}/results [get]
+func (h *ExperimentHandler) GetExperimentResults(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid experiment ID", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.experimentService.GetResults(r.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// WsGroup assigns a websocket connection to the experiment arm of the user_id query parameter
+func (h *ExperimentHandler) WsGroup(r *http.Request) string {
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		return ""
+	}
+	return h.experimentService.GroupOf(userID)
+}
+
+// RegisterRoutes registers the experiment routes
+func (h *ExperimentHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/admin/experiments", h.CreateExperiment).Methods("POST")
+	r.HandleFunc("/admin/experiments", h.ListExperiments).Methods("GET")
+	r.HandleFunc("/admin/experiments/{id}", h.GetExperiment).Methods("GET")
+	r.HandleFunc("/admin/experiments/{id}/start", h.StartExperiment).Methods("POST")
+	r.HandleFunc("/admin/experiments/{id}/stop", h.StopExperiment).Methods("POST")
+	r.HandleFunc("/admin/experiments/{id}/results", h.GetExperimentResults).Methods("GET")
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/trieuvy/video-ranking/internal/services"
+)
+
+// errServed marks commands answered by memoryRedis so they are never sent to a server
+var errServed = errors.New("served from memory")
+
+// memoryRedis is a redis hook answering the SET, SET NX, GET and DEL commands of the idempotency service from a map
+type memoryRedis map[string]string
+
+func (m memoryRedis) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	key := cmd.Args()[1].(string)
+	switch cmd := cmd.(type) {
+	case *redis.BoolCmd:
+		_, exists := m[key]
+		if !exists {
+			m[key] = cmd.Args()[2].(string)
+		}
+		cmd.SetVal(!exists)
+	case *redis.StatusCmd:
+		m[key] = string(cmd.Args()[2].([]byte))
+	case *redis.StringCmd:
+		value, ok := m[key]
+		if !ok {
+			return ctx, redis.Nil
+		}
+		cmd.SetVal(value)
+	case *redis.IntCmd:
+		delete(m, key)
+	}
+	return ctx, errServed
+}
+
+func (m memoryRedis) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if cmd.Err() == errServed {
+		cmd.SetErr(nil)
+	}
+	return nil
+}
+
+func (m memoryRedis) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m memoryRedis) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	type call struct {
+		key        string
+		body       string
+		wantStatus int
+		wantBody   string
+	}
+	tests := []struct {
+		name      string
+		status    int
+		calls     []call
+		wantCalls int
+	}{
+		{
+			name:   "requests without a key are always handled",
+			status: http.StatusCreated,
+			calls: []call{
+				{body: "a", wantStatus: http.StatusCreated, wantBody: "handled 1"},
+				{body: "a", wantStatus: http.StatusCreated, wantBody: "handled 2"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "retry with the same key and body replays the first response",
+			status: http.StatusCreated,
+			calls: []call{
+				{key: "k", body: "a", wantStatus: http.StatusCreated, wantBody: "handled 1"},
+				{key: "k", body: "a", wantStatus: http.StatusCreated, wantBody: "handled 1"},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "reusing a key with another body is rejected",
+			status: http.StatusCreated,
+			calls: []call{
+				{key: "k", body: "a", wantStatus: http.StatusCreated, wantBody: "handled 1"},
+				{key: "k", body: "b", wantStatus: http.StatusUnprocessableEntity},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "server errors release the key",
+			status: http.StatusInternalServerError,
+			calls: []call{
+				{key: "k", body: "a", wantStatus: http.StatusInternalServerError, wantBody: "handled 1"},
+				{key: "k", body: "a", wantStatus: http.StatusInternalServerError, wantBody: "handled 2"},
+			},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisClient := redis.NewClient(&redis.Options{})
+			redisClient.AddHook(memoryRedis{})
+			idempotencyService := services.NewIdempotencyService(redisClient, time.Hour, time.Minute)
+
+			calls := 0
+			handler := Idempotent(idempotencyService, "test", func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, "handled %d", calls)
+			})
+			for i, c := range tt.calls {
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.body))
+				if c.key != "" {
+					r.Header.Set(idempotencyKeyHeader, c.key)
+				}
+				w := httptest.NewRecorder()
+				handler(w, r)
+
+				if w.Code != c.wantStatus {
+					t.Errorf("call %d status = %d, want %d", i+1, w.Code, c.wantStatus)
+				}
+				if c.wantBody != "" && w.Body.String() != c.wantBody {
+					t.Errorf("call %d body = %q, want %q", i+1, w.Body.String(), c.wantBody)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
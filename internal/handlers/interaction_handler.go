@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/trieuvy/video-ranking/internal/models"
 	"github.com/trieuvy/video-ranking/internal/params/request"
 	"github.com/trieuvy/video-ranking/internal/services"
+	"gorm.io/gorm"
 )
 
 // InteractionHandler handles HTTP requests for interactions
@@ -23,16 +26,18 @@ type InteractionHandler struct {
 	interactionService *services.InteractionService
 	videoService       *services.VideoService
 	userService        *services.UserService
-	queueServices      *services.QueueServices
+	backpressure       *services.Backpressure
+	idempotencyService *services.IdempotencyService
 }
 
 // NewInteractionHandler creates a new interaction handler
-func NewInteractionHandler(interactionService *services.InteractionService, videoService *services.VideoService, userService *services.UserService, queueServices *services.QueueServices) *InteractionHandler {
+func NewInteractionHandler(interactionService *services.InteractionService, videoService *services.VideoService, userService *services.UserService, backpressure *services.Backpressure, idempotencyService *services.IdempotencyService) *InteractionHandler {
 	return &InteractionHandler{
 		interactionService: interactionService,
 		videoService:       videoService,
 		userService:        userService,
-		queueServices:      queueServices,
+		backpressure:       backpressure,
+		idempotencyService: idempotencyService,
 	}
 }
 
@@ -43,8 +48,14 @@ func NewInteractionHandler(interactionService *services.InteractionService, vide
 // @Accept json
 // @Produce json
 // @Param interaction body request.Interaction true "Interaction object"
+// @Param Idempotency-Key header string false "Client-generated key; retries with the same key return the original response"
 // @Success 200 {object} models.Interaction
 // @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "User or video not found"
+// @Failure 409 {string} string "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {string} string "Idempotency-Key was used with a different request"
+// @Failure 500 {string} string "Internal server error"
+// @Failure 503 {string} string "Interaction queue is full, retry after the Retry-After header"
 // @Router /interactions [post]
 func (h *InteractionHandler) CreateInteraction(w http.ResponseWriter, r *http.Request) {
 	var interaction request.Interaction
@@ -68,26 +79,29 @@ func (h *InteractionHandler) CreateInteraction(w http.ResponseWriter, r *http.Re
 		Type:    interaction.Type,
 	}
 
-	// Check if UserID exists
-	if _, err := h.userService.GetUser(interactionModel.UserID); err != nil {
+	// Check if UserID exists. Only a missing record is a client error; anything else is answered with a 5xx
+	// so an Idempotency-Key is released and the retry is handled again instead of replaying the failure.
+	if _, err := h.userService.GetUser(interactionModel.UserID); errors.Is(err, gorm.ErrRecordNotFound) {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 	// Check if VideoID exists
-	if _, err := h.videoService.GetVideo(interaction.VideoID); err != nil {
+	if _, err := h.videoService.GetVideo(interaction.VideoID); errors.Is(err, gorm.ErrRecordNotFound) {
 		http.Error(w, "Video not found", http.StatusNotFound)
 		return
-	}
-	if err := h.interactionService.CreateInteraction(&interactionModel); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	interactionEvent := models.InteractionEvent{
-		VideoID: interaction.VideoID,
-		Type:    interaction.Type,
-		Step:    1,
+	// Shed before writing anything so a retried request does not leave a duplicate row behind
+	if !h.admit(w, r, interaction.Type) {
+		return
 	}
-	if err := h.queueServices.EnqueueInteractionEvent(interactionEvent); err != nil {
+	// The outbox event written with the interaction is relayed to the queue to update the counters
+	if err := h.interactionService.CreateInteraction(&interactionModel); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -219,6 +233,7 @@ func (h *InteractionHandler) UpdateInteraction(w http.ResponseWriter, r *http.Re
 // @Success 204 "No Content"
 // @Failure 400 {string} string "Invalid interaction ID"
 // @Failure 500 {string} string "Internal server error"
+// @Failure 503 {string} string "Interaction queue is full, retry after the Retry-After header"
 // @Router /interactions/{id} [delete]
 func (h *InteractionHandler) DeleteInteraction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -232,16 +247,10 @@ func (h *InteractionHandler) DeleteInteraction(w http.ResponseWriter, r *http.Re
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
-	if err := h.interactionService.DeleteInteraction(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if !h.admit(w, r, interaction.Type) {
 		return
 	}
-	interactionEvent := models.InteractionEvent{
-		VideoID: interaction.VideoID,
-		Type:    interaction.Type,
-		Step:    -1,
-	}
-	if err := h.queueServices.EnqueueInteractionEvent(interactionEvent); err != nil {
+	if err := h.interactionService.DeleteInteraction(interaction); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -280,9 +289,25 @@ func (h *InteractionHandler) ListInteractions(w http.ResponseWriter, r *http.Req
 	json.NewEncoder(w).Encode(interactions)
 }
 
+// admit waits for room in the interaction queue and answers 503 with Retry-After when the event is shed
+func (h *InteractionHandler) admit(w http.ResponseWriter, r *http.Request, eventType models.InteractionType) bool {
+	err := h.backpressure.Admit(r.Context(), eventType)
+	if errors.Is(err, services.ErrQueueFull) {
+		retryAfter := int(math.Ceil(h.backpressure.RetryAfter().Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
 // RegisterRoutes registers the interaction routes
 func (h *InteractionHandler) RegisterRoutes(r *mux.Router) {
-	r.HandleFunc("/interactions", h.CreateInteraction).Methods("POST")
+	r.HandleFunc("/interactions", Idempotent(h.idempotencyService, "interactions:create", h.CreateInteraction)).Methods("POST")
 	r.HandleFunc("/interactions/{id}", h.GetInteraction).Methods("GET")
 	r.HandleFunc("/interactions/{id}", h.UpdateInteraction).Methods("PUT")
 	r.HandleFunc("/interactions/{id}", h.DeleteInteraction).Methods("DELETE")
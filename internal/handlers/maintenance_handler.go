@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/params/request"
+	"github.com/trieuvy/video-ranking/internal/services"
+)
+
+// MaintenanceHandler handles HTTP requests for rebuilding and repairing leaderboards
+// @title Maintenance API
+// @description Admin API for rebuilding leaderboards from the interaction log or the database and repairing drifted counters
+type MaintenanceHandler struct {
+	replayService    *services.ReplayService
+	rebuildService   *services.RebuildService
+	reconcileService *services.ReconcileService
+	videoService     *services.VideoService
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(replayService *services.ReplayService, rebuildService *services.RebuildService, reconcileService *services.ReconcileService, videoService *services.VideoService) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		replayService:    replayService,
+		rebuildService:   rebuildService,
+		reconcileService: reconcileService,
+		videoService:     videoService,
+	}
+}
+
+// ReplayLeaderboard handles rebuilding the all-time leaderboard from the interaction log
+// @Summary Replay the interaction log into the leaderboard
+// @Description Sum the logged interaction events since a point in time, score them with the chosen scorer and atomically swap in the all-time and creator leaderboards built from them. Only the leaderboards are replayed: the scores and counters stored with the videos are left unchanged, and the next event of a video rescores it with the configured scorer
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param replay body request.Replay true "Replay options; an empty from replays the whole log and an empty scorer uses the configured one"
+// @Success 200 {object} services.ReplayResult
+// @Failure 400 {string} string "Bad request"
+// @Failure 409 {string} string "A rebuild is already running"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/ranking/replay [post]
+func (h *MaintenanceHandler) ReplayLeaderboard(w http.ResponseWriter, r *http.Request) {
+	var replay request.Replay
+	if err := json.NewDecoder(r.Body).Decode(&replay); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var validate = validator.New()
+	err := validate.Struct(replay)
+	if err != nil {
+		var sb strings.Builder
+		for _, e := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field '%s' failed on the '%s' rule\n", e.Field(), e.Tag()))
+		}
+		http.Error(w, sb.String(), http.StatusBadRequest)
+		return
+	}
+	var from time.Time
+	if replay.From != nil {
+		from = *replay.From
+	}
+
+	result, err := h.replayService.Replay(r.Context(), from, replay.Scorer)
+	if errors.Is(err, services.ErrLockHeld) {
+		http.Error(w, "A rebuild is already running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.notifyTrending(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// RebuildLeaderboard handles restoring the all-time leaderboard from the stored video scores
+// @Summary Rebuild the leaderboard from the database
+// @Description Repopulate the all-time leaderboard from the scores stored with the videos when it is missing or incomplete, for instance after Redis lost its data
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param force query bool false "Rebuild even when the leaderboard looks complete"
+// @Success 200 {object} services.RebuildResult
+// @Failure 400 {string} string "Invalid force"
+// @Failure 409 {string} string "A rebuild is already running"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/ranking/rebuild [post]
+func (h *MaintenanceHandler) RebuildLeaderboard(w http.ResponseWriter, r *http.Request) {
+	force := false
+	if value := r.URL.Query().Get("force"); value != "" {
+		var err error
+		if force, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid force", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.rebuildService.Rebuild(r.Context(), force)
+	if errors.Is(err, services.ErrLockHeld) {
+		http.Error(w, "A rebuild is already running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result.Rebuilt {
+		h.notifyTrending(r)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Reconcile handles recounting video counters from the interactions table
+// @Summary Reconcile video counters and leaderboards
+// @Description Recount the counters of every video from the interactions table, repair drifted counters and scores and remove leaderboard members of deleted videos. Videos with recent or dead-lettered events are skipped.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Only report the drift without repairing it"
+// @Success 200 {object} services.DriftReport
+// @Failure 400 {string} string "Invalid dry_run"
+// @Failure 409 {string} string "A reconciliation is already running"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/reconcile [post]
+func (h *MaintenanceHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.reconcileService.Reconcile(r.Context(), dryRun)
+	if errors.Is(err, services.ErrLockHeld) {
+		http.Error(w, "A reconciliation is already running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetReconcileReport handles retrieving the drift report of the last reconciliation
+// @Summary Get the last drift report
+// @Description Get what the last reconciliation, scheduled or requested, found and repaired
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} services.DriftReport
+// @Failure 404 {string} string "No reconciliation has run yet"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/reconcile/report [get]
+func (h *MaintenanceHandler) GetReconcileReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.reconcileService.LastReport(r.Context())
+	if errors.Is(err, services.ErrNoReconcileReport) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// notifyTrending rebroadcasts the leaderboard so connected clients see the rebuilt one immediately
+func (h *MaintenanceHandler) notifyTrending(r *http.Request) {
+	if err := h.videoService.NotifyTrending(r.Context()); err != nil {
+		log.Printf("Error broadcasting trending videos after maintenance: %v", err)
+	}
+}
+
+// RegisterRoutes registers the maintenance routes
+func (h *MaintenanceHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/admin/ranking/replay", h.ReplayLeaderboard).Methods("POST")
+	r.HandleFunc("/admin/ranking/rebuild", h.RebuildLeaderboard).Methods("POST")
+	r.HandleFunc("/admin/reconcile", h.Reconcile).Methods("POST")
+	r.HandleFunc("/admin/reconcile/report", h.GetReconcileReport).Methods("GET")
+}
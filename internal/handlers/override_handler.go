@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/params/request"
+	"github.com/trieuvy/video-ranking/internal/services"
+)
+
+// OverrideHandler handles HTTP requests for editorial ranking overrides
+// @title Ranking Override API
+// @description Admin API for pinning, boosting, demoting and blacklisting videos
+type OverrideHandler struct {
+	overrideService   *services.OverrideService
+	videoService      *services.VideoService
+	experimentService *services.ExperimentService
+}
+
+// NewOverrideHandler creates a new override handler
+func NewOverrideHandler(overrideService *services.OverrideService, videoService *services.VideoService, experimentService *services.ExperimentService) *OverrideHandler {
+	return &OverrideHandler{
+		overrideService:   overrideService,
+		videoService:      videoService,
+		experimentService: experimentService,
+	}
+}
+
+// CreateOverride handles the creation of a new ranking override
+// @Summary Create a ranking override
+// @Description Pin a video at a position, boost or demote its score, or blacklist it from trending
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param override body request.RankingOverride true "Override object"
+// @Success 200 {object} models.RankingOverride
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Video not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/ranking/overrides [post]
+func (h *OverrideHandler) CreateOverride(w http.ResponseWriter, r *http.Request) {
+	var override request.RankingOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var validate = validator.New()
+	err := validate.Struct(override)
+	if err != nil {
+		var sb strings.Builder
+		for _, e := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field '%s' failed on the '%s' rule\n", e.Field(), e.Tag()))
+		}
+		http.Error(w, sb.String(), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.videoService.GetVideo(override.VideoID); err != nil {
+		http.Error(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	overrideModel := models.RankingOverride{
+		VideoID:    override.VideoID,
+		Action:     override.Action,
+		Position:   override.Position,
+		Multiplier: override.Multiplier,
+		Reason:     override.Reason,
+		ExpiresAt:  override.ExpiresAt,
+	}
+	if err := h.overrideService.CreateOverride(&overrideModel); err != nil {
+		if errors.Is(err, services.ErrInvalidOverride) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.notifyTrending(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(overrideModel)
+}
+
+// GetOverride handles retrieving a ranking override by ID
+// @Summary Get a ranking override by ID
+// @Description Get details of a specific ranking override
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Override ID"
+// @Success 200 {object} models.RankingOverride
+// @Failure 400 {string} string "Invalid override ID"
+// @Failure 404 {string} string "Override not found"
+// @Router /admin/ranking/overrides/{id} [get]
+func (h *OverrideHandler) GetOverride(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid override ID", http.StatusBadRequest)
+		return
+	}
+
+	override, err := h.overrideService.GetOverride(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(override)
+}
+
+// DeleteOverride handles removing a ranking override
+// @Summary Delete a ranking override
+// @Description Delete a ranking override so the video is ranked by its raw score again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Override ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid override ID"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/ranking/overrides/{id} [delete]
+func (h *OverrideHandler) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid override ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.overrideService.DeleteOverride(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.notifyTrending(r)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListOverrides handles retrieving a list of ranking overrides with pagination
+// @Summary List all ranking overrides
+// @Description Get a paginated list of ranking overrides, including expired ones
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Number of items per page"
+// @Success 200 {array} models.RankingOverride
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/ranking/overrides [get]
+func (h *OverrideHandler) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	overrides, err := h.overrideService.ListOverrides(page, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(overrides)
+}
+
+// notifyTrending rebroadcasts the leaderboard and the experiment arm leaderboards so connected clients see the override immediately
+func (h *OverrideHandler) notifyTrending(r *http.Request) {
+	if err := h.videoService.NotifyTrending(r.Context()); err != nil {
+		log.Printf("Error broadcasting trending videos after override change: %v", err)
+	}
+	if err := h.experimentService.NotifyArms(r.Context()); err != nil {
+		log.Printf("Error broadcasting experiment arm leaderboards after override change: %v", err)
+	}
+}
+
+// RegisterRoutes registers the override routes
+func (h *OverrideHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/admin/ranking/overrides", h.CreateOverride).Methods("POST")
+	r.HandleFunc("/admin/ranking/overrides/{id}", h.GetOverride).Methods("GET")
+	r.HandleFunc("/admin/ranking/overrides/{id}", h.DeleteOverride).Methods("DELETE")
+	r.HandleFunc("/admin/ranking/overrides", h.ListOverrides).Methods("GET")
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/services"
+)
+
+// QueueHandler handles HTTP requests for the interaction queue
+// @title Queue API
+// @description Admin API for monitoring the interaction queue
+type QueueHandler struct {
+	backpressure *services.Backpressure
+}
+
+// NewQueueHandler creates a new queue handler
+func NewQueueHandler(backpressure *services.Backpressure) *QueueHandler {
+	return &QueueHandler{
+		backpressure: backpressure,
+	}
+}
+
+// GetQueueStats handles retrieving the depth of the interaction queue
+// @Summary Get interaction queue stats
+// @Description Get the queue depth, the outbox events not relayed to the queue yet, their capacity and how many events of each type were admitted or shed
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} services.QueueStats
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/queue/stats [get]
+func (h *QueueHandler) GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.backpressure.Stats(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// RegisterRoutes registers the queue routes
+func (h *QueueHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/admin/queue/stats", h.GetQueueStats).Methods("GET")
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/trieuvy/video-ranking/internal/services"
+	"gorm.io/gorm"
+)
+
+// TrendingHandler handles HTTP requests for trending leaderboards
+// @title Trending API
+// @description API for reading video leaderboards
+type TrendingHandler struct {
+	trendingService *services.TrendingService
+	snapshotService *services.SnapshotService
+	explainService  *services.ExplainService
+}
+
+// NewTrendingHandler creates a new trending handler
+func NewTrendingHandler(trendingService *services.TrendingService, snapshotService *services.SnapshotService, explainService *services.ExplainService) *TrendingHandler {
+	return &TrendingHandler{
+		trendingService: trendingService,
+		snapshotService: snapshotService,
+		explainService:  explainService,
+	}
+}
+
+// GetTrending handles retrieving a page of a leaderboard with full video details
+// @Summary Get trending videos
+// @Description Get a page of the all-time, hot, rolling-window, category or tag leaderboard with video details, score and rank, with editorial overrides applied
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param window query string false "Leaderboard window: all (default), hot, 1h, 24h or 7d"
+// @Param category query string false "Restrict the all-time leaderboard to a category"
+// @Param tag query string false "Restrict the all-time leaderboard to a tag"
+// @Param user_id query string false "Serve the all-time leaderboard of the experiment arm the user is assigned to"
+// @Param limit query int false "Limit the number of results (default 10, max 100)"
+// @Param offset query int false "Number of entries to skip (default 0)"
+// @Success 200 {array} response.TrendingVideo
+// @Failure 400 {string} string "Invalid window, category, tag, user_id, limit or offset parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /trending [get]
+func (h *TrendingHandler) GetTrending(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	window := query.Get("window")
+	if window != "" && window != services.AllTimeWindow && window != services.HotWindow && !services.IsWindow(window) {
+		http.Error(w, "Invalid window", http.StatusBadRequest)
+		return
+	}
+	category := strings.ToLower(strings.TrimSpace(query.Get("category")))
+	tag := strings.ToLower(strings.TrimSpace(query.Get("tag")))
+	if category != "" && tag != "" {
+		http.Error(w, "Only one of category and tag can be given", http.StatusBadRequest)
+		return
+	}
+	if (category != "" || tag != "") && window != "" && window != services.AllTimeWindow {
+		http.Error(w, "Category and tag leaderboards only support the all window", http.StatusBadRequest)
+		return
+	}
+	var userID uuid.UUID
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		var err error
+		userID, err = uuid.Parse(userIDStr)
+		if err != nil {
+			http.Error(w, "Invalid user_id parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := 10 // Default limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 100 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	videos, err := h.trendingService.GetTrending(r.Context(), services.TrendingQuery{
+		Window:   window,
+		Category: category,
+		Tag:      tag,
+		UserID:   userID,
+		Offset:   offset,
+		Limit:    limit,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(videos)
+}
+
+// GetRising handles retrieving the videos whose engagement rate is spiking
+// @Summary Get rising videos
+// @Description Get videos whose recent engagement rate is growing fastest relative to their own baseline, without blacklisted videos and with boosts and demotions applied
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit the number of results (default 10, max 100)"
+// @Param offset query int false "Number of entries to skip (default 0)"
+// @Success 200 {array} response.RisingVideo
+// @Failure 400 {string} string "Invalid limit or offset parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /rising [get]
+func (h *TrendingHandler) GetRising(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 10 // Default limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 100 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	videos, err := h.trendingService.GetRising(r.Context(), offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(videos)
+}
+
+// GetHotVideos handles retrieving the time-decayed hot leaderboard.
+// It is an alias of GET /trending?window=hot.
+// @Summary Get hot videos
+// @Description Get a page of the videos with the highest time-decayed engagement score, with editorial overrides applied. Alias of GET /trending?window=hot
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit the number of results (default 10, max 100)"
+// @Param offset query int false "Number of entries to skip (default 0)"
+// @Success 200 {array} response.TrendingVideo
+// @Failure 400 {string} string "Invalid limit or offset parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /trending/hot [get]
+func (h *TrendingHandler) GetHotVideos(w http.ResponseWriter, r *http.Request) {
+	h.getWindow(w, r, services.HotWindow)
+}
+
+// GetWindowVideos handles retrieving a rolling-window leaderboard.
+// It is an alias of GET /trending?window={window}.
+// @Summary Get trending videos within a window
+// @Description Get a page of the videos with the highest engagement during the last hour (1h), day (24h) or week (7d), with editorial overrides applied. Alias of GET /trending?window={window}
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param window path string true "Window (1h, 24h or 7d)"
+// @Param limit query int false "Limit the number of results (default 10, max 100)"
+// @Param offset query int false "Number of entries to skip (default 0)"
+// @Success 200 {array} response.TrendingVideo
+// @Failure 400 {string} string "Invalid window, limit or offset parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /trending/windows/{window} [get]
+func (h *TrendingHandler) GetWindowVideos(w http.ResponseWriter, r *http.Request) {
+	window := mux.Vars(r)["window"]
+	if !services.IsWindow(window) {
+		http.Error(w, "Invalid window", http.StatusBadRequest)
+		return
+	}
+	h.getWindow(w, r, window)
+}
+
+// getWindow serves GET /trending for window, ignoring any category, tag or user_id given
+func (h *TrendingHandler) getWindow(w http.ResponseWriter, r *http.Request, window string) {
+	query := r.URL.Query()
+	query.Del("category")
+	query.Del("tag")
+	query.Del("user_id")
+	query.Set("window", window)
+	r.URL.RawQuery = query.Encode()
+	h.GetTrending(w, r)
+}
+
+// GetTrendingHistory handles retrieving the leaderboard as it was at a point in time
+// @Summary Get historical leaderboard
+// @Description Get the most recent leaderboard snapshot taken at or before the given time
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param at query string false "RFC3339 timestamp (default now)"
+// @Success 200 {array} models.LeaderboardSnapshot
+// @Failure 400 {string} string "Invalid at parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /trending/history [get]
+func (h *TrendingHandler) GetTrendingHistory(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			http.Error(w, "Invalid at parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.snapshotService.GetLeaderboardAt(at)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetVideoRankHistory handles retrieving the recorded ranks of a video over time
+// @Summary Get rank history of a video
+// @Description Get every leaderboard snapshot entry of a video between from and to
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param from query string false "RFC3339 start time (default 7 days ago)"
+// @Param to query string false "RFC3339 end time (default now)"
+// @Success 200 {array} models.LeaderboardSnapshot
+// @Failure 400 {string} string "Invalid video ID, from or to parameter"
+// @Failure 500 {string} string "Internal server error"
+// @Router /videos/{id}/rank-history [get]
+func (h *TrendingHandler) GetVideoRankHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid video ID", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now()
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -7)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil || from.After(to) {
+			http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.snapshotService.GetRankHistory(id, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// ExplainRanking handles explaining the score and rank of a video
+// @Summary Explain the ranking of a video
+// @Description Get the scoring strategy, counters, weights, decay, overrides and resulting score and rank of a video
+// @Tags trending
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {object} services.RankingExplanation
+// @Failure 400 {string} string "Invalid video ID"
+// @Failure 404 {string} string "Video not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /videos/{id}/ranking/explain [get]
+func (h *TrendingHandler) ExplainRanking(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid video ID", http.StatusBadRequest)
+		return
+	}
+
+	explanation, err := h.explainService.ExplainRanking(r.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explanation)
+}
+
+// RegisterRoutes registers the trending routes
+func (h *TrendingHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/trending", h.GetTrending).Methods("GET")
+	r.HandleFunc("/trending/hot", h.GetHotVideos).Methods("GET")
+	r.HandleFunc("/trending/history", h.GetTrendingHistory).Methods("GET")
+	r.HandleFunc("/rising", h.GetRising).Methods("GET")
+	r.HandleFunc("/videos/{id}/rank-history", h.GetVideoRankHistory).Methods("GET")
+	r.HandleFunc("/videos/{id}/ranking/explain", h.ExplainRanking).Methods("GET")
+	r.HandleFunc("/trending/windows/{window}", h.GetWindowVideos).Methods("GET")
+}
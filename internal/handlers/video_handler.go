@@ -57,11 +57,12 @@ func (h *VideoHandler) CreateVideo(w http.ResponseWriter, r *http.Request) {
 	videoModel := models.Video{
 		Title:       video.Title,
 		Description: video.Description,
+		CreatedBy:   video.CreatedBy,
 		Views:       0,
 		Likes:       0,
 		Comments:    0,
 	}
-	if err := h.videoService.CreateVideo(&videoModel); err != nil {
+	if err := h.videoService.CreateVideo(&videoModel, video.Categories, video.Tags); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -72,7 +73,7 @@ func (h *VideoHandler) CreateVideo(w http.ResponseWriter, r *http.Request) {
 
 // GetVideo handles retrieving a video by ID
 // @Summary Get a video by ID
-// @Description Get details of a specific video
+// @Description Get details of a specific video with its categories and tags
 // @Tags videos
 // @Accept json
 // @Produce json
@@ -89,7 +90,7 @@ func (h *VideoHandler) GetVideo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	video, err := h.videoService.GetVideo(id)
+	video, err := h.videoService.GetVideoWithTags(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -144,6 +145,13 @@ func (h *VideoHandler) UpdateVideo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	// Categories and tags are only replaced when the request carries them
+	if video.Categories != nil || video.Tags != nil {
+		if err := h.videoService.SetVideoTags(r.Context(), id, video.Categories, video.Tags); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(video)
@@ -1,10 +1,16 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 type InteractionEvent struct {
+	// ID identifies the event across redeliveries so it is applied once, nil for events without an outbox entry
+	ID        uuid.UUID
 	VideoID   uuid.UUID 
+	UserID    uuid.UUID
 	Type InteractionType
 	Step int
+	CreatedAt time.Time
 }
\ No newline at end of file
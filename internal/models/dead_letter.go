@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeadLetter is an interaction event whose counters could not be updated after every retry
+type DeadLetter struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	EventID    uuid.UUID       `json:"event_id" gorm:"type:char(36)"`
+	VideoID    uuid.UUID       `json:"video_id" gorm:"type:char(36);index;not null"`
+	UserID     uuid.UUID       `json:"user_id" gorm:"type:char(36)"`
+	Type       InteractionType `json:"type" gorm:"size:20;not null"`
+	Step       int             `json:"step"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Error      string          `json:"error" gorm:"type:text"`
+	Attempts   int             `json:"attempts"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+}
+
+// NewDeadLetter records an event that failed with err after attempts tries
+func NewDeadLetter(event InteractionEvent, err error, attempts int) DeadLetter {
+	return DeadLetter{
+		EventID:    event.ID,
+		VideoID:    event.VideoID,
+		UserID:     event.UserID,
+		Type:       event.Type,
+		Step:       event.Step,
+		OccurredAt: event.CreatedAt,
+		Error:      err.Error(),
+		Attempts:   attempts,
+	}
+}
+
+// Event returns the dead-lettered interaction event
+func (d *DeadLetter) Event() InteractionEvent {
+	return InteractionEvent{
+		ID:        d.EventID,
+		VideoID:   d.VideoID,
+		UserID:    d.UserID,
+		Type:      d.Type,
+		Step:      d.Step,
+		CreatedAt: d.OccurredAt,
+	}
+}
+
+func (d *DeadLetter) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	d.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Experiment represents an A/B test comparing scoring strategies on live traffic
+type Experiment struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	Name      string          `json:"name" gorm:"size:100;uniqueIndex;not null"`
+	Active    bool            `json:"active" gorm:"default:false"`
+	Arms      []ExperimentArm `json:"arms" gorm:"foreignKey:ExperimentID"`
+	StartedAt *time.Time      `json:"started_at"`
+	StoppedAt *time.Time      `json:"stopped_at"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ExperimentArm represents one scoring strategy of an experiment and its share of users
+type ExperimentArm struct {
+	ID           uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	ExperimentID uuid.UUID `json:"experiment_id" gorm:"type:char(36);index;not null"`
+	Name         string    `json:"name" gorm:"size:50;not null"`
+	Scorer       string    `json:"scorer" gorm:"size:20;not null"`
+	Weight       int       `json:"weight" gorm:"not null"`
+}
+
+func (e *Experiment) BeforeUpdate(tx *gorm.DB) error {
+	e.UpdatedAt = time.Now()
+	return nil
+}
+func (e *Experiment) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = time.Now()
+	return nil
+}
+
+func (a *ExperimentArm) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InteractionLog is an entry of the append-only log of interaction events, kept to rebuild counters and scores
+type InteractionLog struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	VideoID    uuid.UUID       `json:"video_id" gorm:"type:char(36);index;not null"`
+	UserID     uuid.UUID       `json:"user_id" gorm:"type:char(36)"`
+	Type       InteractionType `json:"type" gorm:"size:20;not null"`
+	Step       int             `json:"step"`
+	OccurredAt time.Time       `json:"occurred_at" gorm:"index;not null"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// NewInteractionLog records an interaction event
+func NewInteractionLog(event InteractionEvent) *InteractionLog {
+	return &InteractionLog{
+		VideoID:    event.VideoID,
+		UserID:     event.UserID,
+		Type:       event.Type,
+		Step:       event.Step,
+		OccurredAt: event.CreatedAt,
+	}
+}
+
+func (l *InteractionLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	l.CreatedAt = time.Now()
+	return nil
+}
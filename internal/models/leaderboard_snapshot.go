@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LeaderboardSnapshot records the position of a video in the all-time leaderboard at a point in time
+type LeaderboardSnapshot struct {
+	ID      uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	TakenAt time.Time `json:"taken_at" gorm:"index;not null"`
+	Rank    int       `json:"rank" gorm:"not null"`
+	VideoID uuid.UUID `json:"video_id" gorm:"type:char(36);index;not null"`
+	Score   float64   `json:"score"`
+}
+
+func (s *LeaderboardSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
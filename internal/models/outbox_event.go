@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is an interaction event written in the same transaction as its interaction,
+// waiting to be relayed to the interaction queue
+type OutboxEvent struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	VideoID     uuid.UUID       `json:"video_id" gorm:"type:char(36);not null"`
+	UserID      uuid.UUID       `json:"user_id" gorm:"type:char(36)"`
+	Type        InteractionType `json:"type" gorm:"size:20;not null"`
+	Step        int             `json:"step"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"index"`
+	DeliveredAt *time.Time      `json:"delivered_at" gorm:"index"`
+	// ClaimedUntil keeps other relays off an event while one publishes it
+	ClaimedUntil *time.Time `json:"claimed_until"`
+}
+
+// NewOutboxEvent records that an interaction was added (step 1) or removed (step -1)
+func NewOutboxEvent(interaction *Interaction, step int) *OutboxEvent {
+	return &OutboxEvent{
+		VideoID:    interaction.VideoID,
+		UserID:     interaction.UserID,
+		Type:       interaction.Type,
+		Step:       step,
+		OccurredAt: time.Now(),
+	}
+}
+
+// Event returns the interaction event to relay
+func (o *OutboxEvent) Event() InteractionEvent {
+	return InteractionEvent{
+		ID:        o.ID,
+		VideoID:   o.VideoID,
+		UserID:    o.UserID,
+		Type:      o.Type,
+		Step:      o.Step,
+		CreatedAt: o.OccurredAt,
+	}
+}
+
+func (o *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	o.CreatedAt = time.Now()
+	return nil
+}
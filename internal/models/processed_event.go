@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProcessedEvent records that the counter change of an interaction event was applied,
+// so an event delivered again is not applied twice
+type ProcessedEvent struct {
+	ID          uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	VideoID     uuid.UUID `json:"video_id" gorm:"type:char(36);not null"`
+	ProcessedAt time.Time `json:"processed_at" gorm:"index"`
+}
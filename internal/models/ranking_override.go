@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OverrideAction represents the editorial action applied to a video's ranking
+type OverrideAction string
+
+const (
+	// Pin keeps the video at a fixed leaderboard position
+	Pin OverrideAction = "pin"
+	// Boost multiplies the video's score by a factor greater than 1
+	Boost OverrideAction = "boost"
+	// Demote multiplies the video's score by a factor between 0 and 1
+	Demote OverrideAction = "demote"
+	// Blacklist removes the video from trending leaderboards
+	Blacklist OverrideAction = "blacklist"
+)
+
+// RankingOverride represents an editorial adjustment applied on top of the raw leaderboard scores
+type RankingOverride struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:char(36);primary_key"`
+	VideoID    uuid.UUID      `json:"video_id" gorm:"type:char(36);index;not null"`
+	Action     OverrideAction `json:"action" gorm:"size:20;not null"`
+	Position   int            `json:"position,omitempty"`
+	Multiplier float64        `json:"multiplier,omitempty"`
+	Reason     string         `json:"reason" gorm:"size:255"`
+	ExpiresAt  *time.Time     `json:"expires_at" gorm:"index"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// IsActive reports whether the override has not expired at now
+func (o *RankingOverride) IsActive(now time.Time) bool {
+	return o.ExpiresAt == nil || o.ExpiresAt.After(now)
+}
+
+func (o *RankingOverride) BeforeUpdate(tx *gorm.DB) error {
+	o.UpdatedAt = time.Now()
+	return nil
+}
+func (o *RankingOverride) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// SeedMarker records that a one-off data seed ran, so instances starting together run it only once
+type SeedMarker struct {
+	Name      string    `json:"name" gorm:"size:64;primary_key"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TagKind distinguishes editorial categories from free-form tags
+type TagKind string
+
+const (
+	CategoryTag TagKind = "category"
+	KeywordTag  TagKind = "tag"
+)
+
+// Tag represents a category or tag that videos can be filed under
+type Tag struct {
+	ID        uuid.UUID `json:"-" gorm:"type:char(36);primary_key"`
+	Kind      TagKind   `json:"kind" gorm:"size:20;not null;uniqueIndex:idx_tags_kind_name"`
+	Name      string    `json:"name" gorm:"size:50;not null;uniqueIndex:idx_tags_kind_name"`
+	CreatedAt time.Time `json:"-"`
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	t.CreatedAt = time.Now()
+	return nil
+}
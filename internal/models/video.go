@@ -16,9 +16,21 @@ type Video struct {
 	Likes       int64     `json:"likes" gorm:"default:0"`
 	Comments    int64     `json:"comments" gorm:"default:0"`
 	Score       float64   `json:"score" gorm:"default:0"`
+	Tags        []Tag     `json:"tags,omitempty" gorm:"many2many:video_tags;"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// TagNames returns the names of the video's tags of the given kind
+func (v *Video) TagNames(kind TagKind) []string {
+	var names []string
+	for _, tag := range v.Tags {
+		if tag.Kind == kind {
+			names = append(names, tag.Name)
+		}
+	}
+	return names
+}
 func (v *Video) BeforeUpdate(tx *gorm.DB) error {
 	v.UpdatedAt = time.Now()
 	return nil
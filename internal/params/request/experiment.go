@@ -0,0 +1,14 @@
+package request
+
+// Experiment represents an A/B test of scoring strategies
+type Experiment struct {
+	Name string          `json:"name" validate:"required,min=3,max=100"`
+	Arms []ExperimentArm `json:"arms" validate:"required,min=2,max=10,dive"`
+}
+
+// ExperimentArm represents one scoring strategy of an experiment
+type ExperimentArm struct {
+	Name   string `json:"name" validate:"required,min=1,max=50,alphanum"`
+	Scorer string `json:"scorer" validate:"required,oneof=linear log wilson bayesian"`
+	Weight int    `json:"weight" validate:"required,min=1,max=100"`
+}
@@ -0,0 +1,18 @@
+package request
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// RankingOverride represents an editorial adjustment of a video's ranking
+type RankingOverride struct {
+	VideoID    uuid.UUID             `json:"video_id" validate:"required"`
+	Action     models.OverrideAction `json:"action" validate:"required,oneof=pin boost demote blacklist"`
+	Position   int                   `json:"position" validate:"omitempty,min=1,max=100"`
+	Multiplier float64               `json:"multiplier" validate:"omitempty,gt=0"`
+	Reason     string                `json:"reason" validate:"omitempty,max=255"`
+	ExpiresAt  *time.Time            `json:"expires_at"`
+}
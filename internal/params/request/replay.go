@@ -0,0 +1,9 @@
+package request
+
+import "time"
+
+// Replay selects the interaction log events replayed into the leaderboard and the scorer applied to them
+type Replay struct {
+	From   *time.Time `json:"from"`
+	Scorer string     `json:"scorer" validate:"omitempty,oneof=linear log wilson bayesian"`
+}
@@ -12,6 +12,8 @@ type Video struct {
 	Likes       int64     `json:"likes"`
 	Comments    int64     `json:"comments"`
 	Score       float64   `json:"score"`
+	Categories  []string  `json:"categories" validate:"omitempty,max=5,dive,min=2,max=50"`
+	Tags        []string  `json:"tags" validate:"omitempty,max=20,dive,min=2,max=50"`
 }
 type VideoUpdate struct {
 	Title       string `json:"title" validate:"required,min=3"`
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// TrendingVideo represents a video on a leaderboard together with its position
+type TrendingVideo struct {
+	Rank        int       `json:"rank"`
+	Score       float64   `json:"score"`
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatedBy   uuid.UUID `json:"user_id"`
+	Views       int64     `json:"views"`
+	Likes       int64     `json:"likes"`
+	Comments    int64     `json:"comments"`
+	TotalScore  float64   `json:"total_score"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Pinned is set when an editorial override holds the video at its rank
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// NewTrendingVideo builds a leaderboard entry from a video and its leaderboard score
+func NewTrendingVideo(rank int, score float64, video models.Video) TrendingVideo {
+	return TrendingVideo{
+		Rank:        rank,
+		Score:       score,
+		ID:          video.ID,
+		Title:       video.Title,
+		Description: video.Description,
+		CreatedBy:   video.CreatedBy,
+		Views:       video.Views,
+		Likes:       video.Likes,
+		Comments:    video.Comments,
+		TotalScore:  video.Score,
+		CreatedAt:   video.CreatedAt,
+	}
+}
+
+// RisingVideo represents a video on the rising leaderboard with its engagement rates
+type RisingVideo struct {
+	TrendingVideo
+	RecentRate   float64 `json:"recent_rate"`
+	BaselineRate float64 `json:"baseline_rate"`
+}
+
+// TrendingCreator represents a creator on the creator leaderboard
+type TrendingCreator struct {
+	Rank     int       `json:"rank"`
+	Score    float64   `json:"score"`
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+)
+
+// DeadLetterRepository handles database operations for dead-lettered interaction events
+type DeadLetterRepository struct {
+	db *gorm.DB
+}
+
+// NewDeadLetterRepository creates a new dead letter repository
+func NewDeadLetterRepository(db *gorm.DB) *DeadLetterRepository {
+	return &DeadLetterRepository{db: db}
+}
+
+// CreateBatch saves dead-lettered events
+func (r *DeadLetterRepository) CreateBatch(deadLetters []models.DeadLetter) error {
+	if len(deadLetters) == 0 {
+		return nil
+	}
+	return r.db.Create(&deadLetters).Error
+}
+
+// FindByID retrieves a dead-lettered event by ID
+func (r *DeadLetterRepository) FindByID(id uuid.UUID) (*models.DeadLetter, error) {
+	var deadLetter models.DeadLetter
+	err := r.db.First(&deadLetter, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetter, nil
+}
+
+// List retrieves dead-lettered events with pagination, oldest first
+func (r *DeadLetterRepository) List(offset, limit int) ([]models.DeadLetter, error) {
+	var deadLetters []models.DeadLetter
+	err := r.db.Order("created_at ASC").Offset(offset).Limit(limit).Find(&deadLetters).Error
+	return deadLetters, err
+}
+
+// FindVideoIDs returns which of the given videos have dead-lettered events
+func (r *DeadLetterRepository) FindVideoIDs(videoIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var found []uuid.UUID
+	if len(videoIDs) == 0 {
+		return found, nil
+	}
+	err := r.db.Model(&models.DeadLetter{}).
+		Distinct("video_id").
+		Where("video_id IN ?", videoIDs).
+		Pluck("video_id", &found).Error
+	return found, err
+}
+
+// Delete removes a dead-lettered event
+func (r *DeadLetterRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.DeadLetter{}, "id = ?", id).Error
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+)
+
+// ExperimentRepository handles database operations for ranking experiments
+type ExperimentRepository struct {
+	db *gorm.DB
+}
+
+// NewExperimentRepository creates a new experiment repository
+func NewExperimentRepository(db *gorm.DB) *ExperimentRepository {
+	return &ExperimentRepository{db: db}
+}
+
+// orderArms loads arms in name order so users are bucketed into the same arm on every instance and every load
+func orderArms(db *gorm.DB) *gorm.DB {
+	return db.Order("name ASC")
+}
+
+// Create saves a new experiment and its arms to the database
+func (r *ExperimentRepository) Create(experiment *models.Experiment) error {
+	return r.db.Create(experiment).Error
+}
+
+// FindByID retrieves an experiment and its arms by ID
+func (r *ExperimentRepository) FindByID(id uuid.UUID) (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := r.db.Preload("Arms", orderArms).First(&experiment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// FindActive retrieves the running experiment and its arms
+func (r *ExperimentRepository) FindActive() (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := r.db.Preload("Arms", orderArms).Where("active = ?", true).First(&experiment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// List retrieves all experiments and their arms with pagination, newest first
+func (r *ExperimentRepository) List(offset, limit int) ([]models.Experiment, error) {
+	var experiments []models.Experiment
+	err := r.db.Preload("Arms", orderArms).Order("created_at DESC").Offset(offset).Limit(limit).Find(&experiments).Error
+	return experiments, err
+}
+
+// Start marks an experiment as the only running one
+func (r *ExperimentRepository) Start(id uuid.UUID, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Experiment{}).
+			Where("active = ? AND id <> ?", true, id).
+			Updates(map[string]interface{}{"active": false, "stopped_at": at}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Experiment{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"active": true, "started_at": at, "stopped_at": nil}).Error
+	})
+}
+
+// Stop marks an experiment as no longer running
+func (r *ExperimentRepository) Stop(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.Experiment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"active": false, "stopped_at": at}).Error
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CounterTotals is the sum of the interaction events of a video
+type CounterTotals struct {
+	VideoID  uuid.UUID
+	Likes    int64
+	Views    int64
+	Comments int64
+}
+
+// interactionLogSeed names the marker of the seed of the interaction log
+const interactionLogSeed = "interaction_logs"
+
+// InteractionLogRepository handles database operations for the interaction event log
+type InteractionLogRepository struct {
+	db *gorm.DB
+}
+
+// NewInteractionLogRepository creates a new interaction log repository
+func NewInteractionLogRepository(db *gorm.DB) *InteractionLogRepository {
+	return &InteractionLogRepository{db: db}
+}
+
+// SeedFromInteractions fills an empty log with one entry per existing interaction,
+// so interactions recorded before the log existed are not lost on replay.
+// The seed inserts its marker in the same transaction, so an instance starting concurrently blocks on the
+// marker's primary key until the first one commits and then skips the seed instead of copying the interactions again.
+func (r *InteractionLogRepository) SeedFromInteractions() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SeedMarker{Name: interactionLogSeed})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		// The log may have been filled before seeds were marked
+		var count int64
+		if err := tx.Model(&models.InteractionLog{}).Limit(1).Count(&count).Error; err != nil || count > 0 {
+			return err
+		}
+		return tx.Exec(`INSERT INTO interaction_logs (id, video_id, user_id, type, step, occurred_at, created_at)
+		SELECT UUID(), video_id, user_id, type, 1, created_at, NOW() FROM interactions`).Error
+	})
+}
+
+// SumSince sums the events of every existing video that occurred at or after from
+func (r *InteractionLogRepository) SumSince(from time.Time) ([]CounterTotals, error) {
+	var totals []CounterTotals
+	err := r.db.Model(&models.InteractionLog{}).
+		Select(`interaction_logs.video_id AS video_id,
+			SUM(CASE WHEN interaction_logs.type = ? THEN interaction_logs.step ELSE 0 END) AS likes,
+			SUM(CASE WHEN interaction_logs.type = ? THEN interaction_logs.step ELSE 0 END) AS views,
+			SUM(CASE WHEN interaction_logs.type = ? THEN interaction_logs.step ELSE 0 END) AS comments`,
+			models.Like, models.View, models.Comment).
+		Joins("JOIN videos ON videos.id = interaction_logs.video_id").
+		Where("interaction_logs.occurred_at >= ?", from).
+		Group("interaction_logs.video_id").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// FindVideosActiveSince returns which of the given videos have events that occurred at or after since
+func (r *InteractionLogRepository) FindVideosActiveSince(videoIDs []uuid.UUID, since time.Time) ([]uuid.UUID, error) {
+	var active []uuid.UUID
+	if len(videoIDs) == 0 {
+		return active, nil
+	}
+	err := r.db.Model(&models.InteractionLog{}).
+		Distinct("video_id").
+		Where("video_id IN ? AND occurred_at >= ?", videoIDs, since).
+		Pluck("video_id", &active).Error
+	return active, err
+}
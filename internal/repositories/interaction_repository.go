@@ -21,6 +21,16 @@ func (r *InteractionRepository) Create(interaction *models.Interaction) error {
 	return r.db.Create(interaction).Error
 }
 
+// CreateWithOutbox saves a new interaction, its outbox event and its log entry in a single transaction
+func (r *InteractionRepository) CreateWithOutbox(interaction *models.Interaction, event *models.OutboxEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(interaction).Error; err != nil {
+			return err
+		}
+		return createOutboxEvent(tx, event)
+	})
+}
+
 // FindByID retrieves an interaction by ID
 func (r *InteractionRepository) FindByID(id uuid.UUID) (*models.Interaction, error) {
 	var interaction models.Interaction
@@ -48,6 +58,47 @@ func (r *InteractionRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Interaction{}, "id = ?", id).Error
 }
 
+// DeleteWithOutbox removes an interaction and saves its outbox event and log entry in a single transaction
+func (r *InteractionRepository) DeleteWithOutbox(id uuid.UUID, event *models.OutboxEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Interaction{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		// Nothing to count if another request removed the interaction first
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return createOutboxEvent(tx, event)
+	})
+}
+
+// createOutboxEvent saves an outbox event and appends it to the interaction log
+func createOutboxEvent(tx *gorm.DB, event *models.OutboxEvent) error {
+	if err := tx.Create(event).Error; err != nil {
+		return err
+	}
+	return tx.Create(models.NewInteractionLog(event.Event())).Error
+}
+
+// CountByVideos counts the likes, views and comments of each of the given videos
+func (r *InteractionRepository) CountByVideos(videoIDs []uuid.UUID) ([]CounterTotals, error) {
+	var totals []CounterTotals
+	if len(videoIDs) == 0 {
+		return totals, nil
+	}
+	err := r.db.Model(&models.Interaction{}).
+		Select(`video_id,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS likes,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS views,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS comments`,
+			models.Like, models.View, models.Comment).
+		Where("video_id IN ?", videoIDs).
+		Group("video_id").
+		Scan(&totals).Error
+	return totals, err
+}
+
 // List retrieves all interactions with pagination
 func (r *InteractionRepository) List(offset, limit int) ([]models.Interaction, error) {
 	var interactions []models.Interaction
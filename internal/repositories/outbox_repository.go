@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository handles database operations for outbox events
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// ClaimBatch claims up to limit undelivered events not claimed by another relay, oldest first, until claimTTL
+// from now and returns them. Rows locked by another relay are skipped and the claim is committed before returning
+// so no transaction stays open while the events are published.
+func (r *OutboxRepository) ClaimBatch(limit int, claimTTL time.Duration) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered_at IS NULL AND (claimed_until IS NULL OR claimed_until < ?)", now).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", outboxIDs(events)).Update("claimed_until", now.Add(claimTTL)).Error
+	})
+	return events, err
+}
+
+// MarkDelivered marks claimed events delivered
+func (r *OutboxRepository) MarkDelivered(events []models.OutboxEvent) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id IN ?", outboxIDs(events)).Update("delivered_at", time.Now()).Error
+}
+
+// Release drops the claim on events that could not be published so they are relayed again right away
+func (r *OutboxRepository) Release(events []models.OutboxEvent) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id IN ?", outboxIDs(events)).Update("claimed_until", nil).Error
+}
+
+// CountUndelivered returns the number of events not delivered yet
+func (r *OutboxRepository) CountUndelivered() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OutboxEvent{}).Where("delivered_at IS NULL").Count(&count).Error
+	return count, err
+}
+
+// FindUnprocessedVideoIDs returns which of the given videos have events not processed yet,
+// whether still waiting in the outbox or delivered to the queue but not applied
+func (r *OutboxRepository) FindUnprocessedVideoIDs(videoIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var found []uuid.UUID
+	if len(videoIDs) == 0 {
+		return found, nil
+	}
+	err := r.db.Model(&models.OutboxEvent{}).
+		Joins("LEFT JOIN processed_events ON processed_events.id = outbox_events.id").
+		Distinct("outbox_events.video_id").
+		Where("outbox_events.video_id IN ? AND processed_events.id IS NULL", videoIDs).
+		Pluck("outbox_events.video_id", &found).Error
+	return found, err
+}
+
+// DeleteDeliveredBefore removes the events delivered before t
+func (r *OutboxRepository) DeleteDeliveredBefore(t time.Time) error {
+	return r.db.Where("delivered_at < ?", t).Delete(&models.OutboxEvent{}).Error
+}
+
+// DeleteProcessedBefore removes the records of events processed before t.
+// They must outlive the delivered events since a delivered event may still be redelivered by the queue.
+func (r *OutboxRepository) DeleteProcessedBefore(t time.Time) error {
+	return r.db.Where("processed_at < ?", t).Delete(&models.ProcessedEvent{}).Error
+}
+
+// outboxIDs returns the IDs of events
+func outboxIDs(events []models.OutboxEvent) []interface{} {
+	ids := make([]interface{}, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
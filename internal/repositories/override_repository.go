@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+)
+
+// OverrideRepository handles database operations for ranking overrides
+type OverrideRepository struct {
+	db *gorm.DB
+}
+
+// NewOverrideRepository creates a new override repository
+func NewOverrideRepository(db *gorm.DB) *OverrideRepository {
+	return &OverrideRepository{db: db}
+}
+
+// Create saves a new override to the database
+func (r *OverrideRepository) Create(override *models.RankingOverride) error {
+	return r.db.Create(override).Error
+}
+
+// FindByID retrieves an override by ID
+func (r *OverrideRepository) FindByID(id uuid.UUID) (*models.RankingOverride, error) {
+	var override models.RankingOverride
+	err := r.db.First(&override, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// Delete removes an override from the database
+func (r *OverrideRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.RankingOverride{}, "id = ?", id).Error
+}
+
+// List retrieves all overrides with pagination, newest first
+func (r *OverrideRepository) List(offset, limit int) ([]models.RankingOverride, error) {
+	var overrides []models.RankingOverride
+	err := r.db.Order("created_at DESC").Offset(offset).Limit(limit).Find(&overrides).Error
+	return overrides, err
+}
+
+// FindActive retrieves every override that has not expired at now, oldest first
+func (r *OverrideRepository) FindActive(now time.Time) ([]models.RankingOverride, error) {
+	var overrides []models.RankingOverride
+	err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).Order("created_at ASC").Find(&overrides).Error
+	return overrides, err
+}
+
+// FindExpiredBetween retrieves the overrides that expired after from and no later than to
+func (r *OverrideRepository) FindExpiredBetween(from, to time.Time) ([]models.RankingOverride, error) {
+	var overrides []models.RankingOverride
+	err := r.db.Where("expires_at > ? AND expires_at <= ?", from, to).Find(&overrides).Error
+	return overrides, err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+)
+
+// SnapshotRepository handles database operations for leaderboard snapshots
+type SnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewSnapshotRepository creates a new snapshot repository
+func NewSnapshotRepository(db *gorm.DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// CreateBatch saves all entries of a snapshot
+func (r *SnapshotRepository) CreateBatch(entries []models.LeaderboardSnapshot) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Create(&entries).Error
+}
+
+// DeleteBefore removes the entries of the snapshots taken before t
+func (r *SnapshotRepository) DeleteBefore(t time.Time) error {
+	return r.db.Where("taken_at < ?", t).Delete(&models.LeaderboardSnapshot{}).Error
+}
+
+// FindLatestAt retrieves the entries of the most recent snapshot taken at or before at
+func (r *SnapshotRepository) FindLatestAt(at time.Time) ([]models.LeaderboardSnapshot, error) {
+	var entries []models.LeaderboardSnapshot
+	latest := r.db.Model(&models.LeaderboardSnapshot{}).Select("MAX(taken_at)").Where("taken_at <= ?", at)
+	err := r.db.Where("taken_at = (?)", latest).Order("`rank` ASC").Find(&entries).Error
+	return entries, err
+}
+
+// FindByVideo retrieves the snapshot entries of a video between from and to
+func (r *SnapshotRepository) FindByVideo(videoID uuid.UUID, from, to time.Time) ([]models.LeaderboardSnapshot, error) {
+	var entries []models.LeaderboardSnapshot
+	err := r.db.Where("video_id = ? AND taken_at BETWEEN ? AND ?", videoID, from, to).
+		Order("taken_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagRepository handles database operations for categories and tags
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// FindOrCreate retrieves the tags of the given kind and names, creating the missing ones
+func (r *TagRepository) FindOrCreate(kind models.TagKind, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+	candidates := make([]models.Tag, len(names))
+	for i, name := range names {
+		candidates[i] = models.Tag{Kind: kind, Name: name}
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&candidates).Error; err != nil {
+		return nil, err
+	}
+	err := r.db.Where("kind = ? AND name IN ?", kind, names).Find(&tags).Error
+	return tags, err
+}
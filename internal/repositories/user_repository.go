@@ -31,6 +31,16 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// FindByIDs retrieves all users whose ID is in ids
+func (r *UserRepository) FindByIDs(ids []uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // FindByEmail retrieves a user by email
 func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
@@ -1,11 +1,19 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/trieuvy/video-ranking/internal/models"
 	"gorm.io/gorm"
 )
 
+// CreatorScore is the sum of the scores of the videos of a creator
+type CreatorScore struct {
+	CreatedBy uuid.UUID
+	Score     float64
+}
+
 // VideoRepository handles database operations for videos
 type VideoRepository struct {
 	db *gorm.DB
@@ -16,7 +24,7 @@ func NewVideoRepository(db *gorm.DB) *VideoRepository {
 	return &VideoRepository{db: db}
 }
 
-// Create saves a new video to the database
+// Create saves a new video to the database together with its tag associations
 func (r *VideoRepository) Create(video *models.Video) error {
 	return r.db.Create(video).Error
 }
@@ -31,10 +39,29 @@ func (r *VideoRepository) FindByID(id uuid.UUID) (*models.Video, error) {
 	return &video, nil
 }
 
-// FindByUser retrieves all videos by a user
-func (r *VideoRepository) FindByUser(userID uuid.UUID) ([]models.Video, error) {
+// FindByIDWithTags retrieves a video by ID together with its categories and tags
+func (r *VideoRepository) FindByIDWithTags(id uuid.UUID) (*models.Video, error) {
+	var video models.Video
+	err := r.db.Preload("Tags").First(&video, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// ReplaceTags replaces the categories and tags of a video
+func (r *VideoRepository) ReplaceTags(video *models.Video, tags []models.Tag) error {
+	return r.db.Model(video).Association("Tags").Replace(tags)
+}
+
+// FindByUser retrieves the videos created by a user, highest score first, with pagination
+func (r *VideoRepository) FindByUser(userID uuid.UUID, offset, limit int) ([]models.Video, error) {
 	var videos []models.Video
-	err := r.db.Where("user_id = ?", userID).Find(&videos).Error
+	err := r.db.Where("created_by = ?", userID).
+		Order("score DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&videos).Error
 	return videos, err
 }
 
@@ -43,9 +70,9 @@ func (r *VideoRepository) Update(video *models.Video) error {
 	return r.db.Save(video).Error
 }
 
-// Delete removes a video from the database
+// Delete removes a video and its category and tag links from the database
 func (r *VideoRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Video{}, "id = ?", id).Error
+	return r.db.Select("Tags").Delete(&models.Video{ID: id}).Error
 }
 
 // List retrieves all videos with pagination
@@ -75,6 +102,126 @@ func (r *VideoRepository) ChangeComments(id uuid.UUID, step int) error {
 	return r.db.Model(&models.Video{}).Where("id = ?", id).Update("comments", gorm.Expr("comments + ?", step)).Error
 }
 
+// changeCounters applies the net change of the like, view and comment counts of a video in a single statement
+func changeCounters(db *gorm.DB, id uuid.UUID, likes, views, comments int64) error {
+	changes := make(map[string]interface{}, 3)
+	if likes != 0 {
+		changes["likes"] = gorm.Expr("likes + ?", likes)
+	}
+	if views != 0 {
+		changes["views"] = gorm.Expr("views + ?", views)
+	}
+	if comments != 0 {
+		changes["comments"] = gorm.Expr("comments + ?", comments)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return db.Model(&models.Video{}).Where("id = ?", id).Updates(changes).Error
+}
+
+// ApplyEvents applies the counter changes of the events of a video that were not applied before, in a transaction
+// that records them as processed, so an event delivered again never changes the counters twice.
+// Events without an ID are always applied. It returns the events that were applied.
+func (r *VideoRepository) ApplyEvents(id uuid.UUID, events []models.InteractionEvent) ([]models.InteractionEvent, error) {
+	var applied []models.InteractionEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		applied = applied[:0]
+		ids := make([]uuid.UUID, 0, len(events))
+		for _, event := range events {
+			if event.ID != uuid.Nil {
+				ids = append(ids, event.ID)
+			}
+		}
+		var processed []uuid.UUID
+		if len(ids) > 0 {
+			if err := tx.Model(&models.ProcessedEvent{}).Where("id IN ?", ids).Pluck("id", &processed).Error; err != nil {
+				return err
+			}
+		}
+		seen := make(map[uuid.UUID]bool, len(processed))
+		for _, eventID := range processed {
+			seen[eventID] = true
+		}
+
+		var likes, views, comments int64
+		var records []models.ProcessedEvent
+		now := time.Now()
+		for _, event := range events {
+			if event.ID != uuid.Nil {
+				if seen[event.ID] {
+					continue
+				}
+				seen[event.ID] = true
+				records = append(records, models.ProcessedEvent{ID: event.ID, VideoID: id, ProcessedAt: now})
+			}
+			switch event.Type {
+			case models.Like:
+				likes += int64(event.Step)
+			case models.View:
+				views += int64(event.Step)
+			case models.Comment:
+				comments += int64(event.Step)
+			}
+			applied = append(applied, event)
+		}
+		// A concurrent consumer that applied the same event makes this insert fail on the primary key,
+		// rolling back the counter change so the retry skips the event
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+		}
+		return changeCounters(tx, id, likes, views, comments)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// SetCounters overwrites the like, view and comment counts of a video
+func (r *VideoRepository) SetCounters(id uuid.UUID, likes, views, comments int64) error {
+	return r.db.Model(&models.Video{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"likes":    likes,
+		"views":    views,
+		"comments": comments,
+	}).Error
+}
+
+// ListAfter retrieves up to limit videos ordered by ID, starting after afterID, so batches stay stable while videos are added
+func (r *VideoRepository) ListAfter(afterID uuid.UUID, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&videos).Error
+	return videos, err
+}
+
+// CountScored counts the videos with a non-zero score
+func (r *VideoRepository) CountScored() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Video{}).Where("score <> 0").Count(&count).Error
+	return count, err
+}
+
+// ListScoredAfter retrieves up to limit videos with a non-zero score ordered by ID, starting after afterID
+func (r *VideoRepository) ListScoredAfter(afterID uuid.UUID, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := r.db.Where("id > ? AND score <> 0", afterID).Order("id ASC").Limit(limit).Find(&videos).Error
+	return videos, err
+}
+
+// SumScoresByCreator sums the scores of the videos of every creator whose videos have a non-zero total
+func (r *VideoRepository) SumScoresByCreator() ([]CreatorScore, error) {
+	var scores []CreatorScore
+	err := r.db.Model(&models.Video{}).
+		Select("created_by, SUM(score) AS score").
+		Where("created_by <> ?", uuid.Nil).
+		Group("created_by").
+		Having("SUM(score) <> 0").
+		Scan(&scores).Error
+	return scores, err
+}
+
 // FindTopViewedByUser retrieves the top 10 highest-scoring videos viewed by a specific user
 func (r *VideoRepository) FindTopViewedByUser(userID uuid.UUID, limit int) ([]models.Video, error) {
 	var videos []models.Video
@@ -87,3 +234,13 @@ func (r *VideoRepository) FindTopViewedByUser(userID uuid.UUID, limit int) ([]mo
 		Find(&videos).Error
 	return videos, err
 }
+
+// FindByIDs retrieves all videos whose ID is in ids
+func (r *VideoRepository) FindByIDs(ids []uuid.UUID) ([]models.Video, error) {
+	var videos []models.Video
+	if len(ids) == 0 {
+		return videos, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&videos).Error
+	return videos, err
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// backpressurePoll is how often the queue depth is checked while waiting for room
+const backpressurePoll = 25 * time.Millisecond
+
+// ErrQueueFull is returned when an event is shed because the queue is over capacity
+var ErrQueueFull = errors.New("interaction queue is full")
+
+// OutboxBacklog counts the admitted events still waiting in the outbox to be relayed to the queue
+type OutboxBacklog interface {
+	Backlog(ctx context.Context) (int64, error)
+}
+
+// QueueStats describes the depth of the interaction queue and how many events were admitted or shed.
+// Depth counts the events in the queue and OutboxBacklog those not relayed to it yet; capacity bounds their sum.
+type QueueStats struct {
+	Depth         int64                            `json:"depth"`
+	OutboxBacklog int64                            `json:"outbox_backlog"`
+	Capacity      int64                            `json:"capacity"`
+	ViewLimit     int64                            `json:"view_limit"`
+	Admitted      map[models.InteractionType]int64 `json:"admitted"`
+	Shed          map[models.InteractionType]int64 `json:"shed"`
+}
+
+// Backpressure admits events while the queue depth plus the outbox backlog is below capacity.
+// Views are shed as soon as the depth reaches viewLimit, leaving the remaining room to likes and comments,
+// which wait up to maxWait for room before being shed.
+type Backpressure struct {
+	queue      EventQueue
+	outbox     OutboxBacklog
+	capacity   int64
+	viewLimit  int64
+	maxWait    time.Duration
+	retryAfter time.Duration
+
+	lock     sync.Mutex
+	admitted map[models.InteractionType]int64
+	shed     map[models.InteractionType]int64
+}
+
+// NewBackpressure creates a new backpressure policy.
+// viewShare is the fraction of capacity views may fill; clients are told to retry after retryAfter.
+func NewBackpressure(queue EventQueue, outbox OutboxBacklog, capacity int64, viewShare float64, maxWait, retryAfter time.Duration) *Backpressure {
+	if viewShare <= 0 || viewShare > 1 {
+		viewShare = 1
+	}
+	return &Backpressure{
+		queue:      queue,
+		outbox:     outbox,
+		capacity:   capacity,
+		viewLimit:  int64(float64(capacity) * viewShare),
+		maxWait:    maxWait,
+		retryAfter: retryAfter,
+		admitted:   make(map[models.InteractionType]int64),
+		shed:       make(map[models.InteractionType]int64),
+	}
+}
+
+// Admit returns nil once there is room for an event of the given type, or ErrQueueFull
+func (b *Backpressure) Admit(ctx context.Context, eventType models.InteractionType) error {
+	limit := b.capacity
+	if eventType == models.View {
+		limit = b.viewLimit
+	}
+	deadline := time.Now().Add(b.maxWait)
+	for {
+		depth, backlog, err := b.depth(ctx)
+		if err != nil {
+			return err
+		}
+		if depth+backlog < limit {
+			b.count(b.admitted, eventType)
+			return nil
+		}
+		// Excess views are dropped right away instead of competing with likes and comments for room
+		if eventType == models.View || !time.Now().Before(deadline) {
+			b.count(b.shed, eventType)
+			return ErrQueueFull
+		}
+		select {
+		case <-time.After(backpressurePoll):
+		case <-ctx.Done():
+			b.count(b.shed, eventType)
+			return ErrQueueFull
+		}
+	}
+}
+
+// RetryAfter returns how long shed clients should wait before retrying
+func (b *Backpressure) RetryAfter() time.Duration {
+	return b.retryAfter
+}
+
+// Stats returns the current queue depth, the outbox backlog and the admission counters
+func (b *Backpressure) Stats(ctx context.Context) (QueueStats, error) {
+	depth, backlog, err := b.depth(ctx)
+	if err != nil {
+		return QueueStats{}, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	stats := QueueStats{
+		Depth:         depth,
+		OutboxBacklog: backlog,
+		Capacity:      b.capacity,
+		ViewLimit:     b.viewLimit,
+		Admitted:      make(map[models.InteractionType]int64, len(b.admitted)),
+		Shed:          make(map[models.InteractionType]int64, len(b.shed)),
+	}
+	for eventType, count := range b.admitted {
+		stats.Admitted[eventType] = count
+	}
+	for eventType, count := range b.shed {
+		stats.Shed[eventType] = count
+	}
+	return stats, nil
+}
+
+// depth returns the number of events in the queue and in the outbox backlog
+func (b *Backpressure) depth(ctx context.Context) (int64, int64, error) {
+	depth, err := b.queue.Len(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	backlog, err := b.outbox.Backlog(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return depth, backlog, nil
+}
+
+// count increments the counter of an event type
+func (b *Backpressure) count(counters map[models.InteractionType]int64, eventType models.InteractionType) {
+	b.lock.Lock()
+	counters[eventType]++
+	b.lock.Unlock()
+}
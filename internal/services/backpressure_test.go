@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// fixedDepth is an EventQueue and OutboxBacklog of fixed sizes, for exercising admission
+type fixedDepth struct {
+	EventQueue
+	depth, backlog int64
+}
+
+func (d *fixedDepth) Len(ctx context.Context) (int64, error) {
+	return d.depth, nil
+}
+
+func (d *fixedDepth) Backlog(ctx context.Context) (int64, error) {
+	return d.backlog, nil
+}
+
+func TestBackpressureAdmit(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int64
+		backlog   int64
+		eventType models.InteractionType
+		wantErr   error
+	}{
+		{name: "view below the view limit is admitted", depth: 79, eventType: models.View},
+		{name: "view at the view limit is shed", depth: 80, eventType: models.View, wantErr: ErrQueueFull},
+		{name: "like at the view limit is admitted", depth: 80, eventType: models.Like},
+		{name: "like at capacity is shed after waiting", depth: 100, eventType: models.Like, wantErr: ErrQueueFull},
+		{name: "outbox backlog counts towards capacity", depth: 60, backlog: 40, eventType: models.Comment, wantErr: ErrQueueFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depth := &fixedDepth{depth: tt.depth, backlog: tt.backlog}
+			backpressure := NewBackpressure(depth, depth, 100, 0.8, 10*time.Millisecond, time.Second)
+
+			if err := backpressure.Admit(context.Background(), tt.eventType); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Admit returned %v, want %v", err, tt.wantErr)
+			}
+			stats, err := backpressure.Stats(context.Background())
+			if err != nil {
+				t.Fatalf("Stats returned error: %v", err)
+			}
+			counters := stats.Admitted
+			if tt.wantErr != nil {
+				counters = stats.Shed
+			}
+			if counters[tt.eventType] != 1 || stats.Depth != tt.depth || stats.OutboxBacklog != tt.backlog {
+				t.Errorf("stats = %+v, want the %s counted once", stats, tt.eventType)
+			}
+		})
+	}
+}
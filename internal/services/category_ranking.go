@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/ws"
+)
+
+// tagScoresPrefix prefixes the per-category and per-tag leaderboards
+const tagScoresPrefix = "video:scores:"
+
+// tagScoresKey returns the key of the leaderboard of a category or tag
+func tagScoresKey(tag models.Tag) string {
+	return tagScoresPrefix + string(tag.Kind) + ":" + tag.Name
+}
+
+// NormalizeTagNames lowercases and trims names, dropping empty and duplicate ones
+func NormalizeTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+// SetVideoTags replaces the categories and tags of a video and moves it between the matching leaderboards.
+// A nil slice keeps the current categories or tags.
+func (s *VideoService) SetVideoTags(ctx context.Context, videoID uuid.UUID, categories, tags []string) error {
+	video, err := s.repo.FindByIDWithTags(videoID)
+	if err != nil {
+		return err
+	}
+	if categories == nil {
+		categories = video.TagNames(models.CategoryTag)
+	}
+	if tags == nil {
+		tags = video.TagNames(models.KeywordTag)
+	}
+
+	newTags, err := s.findOrCreateTags(categories, tags)
+	if err != nil {
+		return err
+	}
+	oldTags := video.Tags
+	if err := s.repo.ReplaceTags(video, newTags); err != nil {
+		return err
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	for _, tag := range oldTags {
+		pipe.ZRem(ctx, tagScoresKey(tag), videoID.String())
+	}
+	for _, tag := range newTags {
+		pipe.ZAdd(ctx, tagScoresKey(tag), &redis.Z{
+			Score:  video.Score,
+			Member: videoID.String(),
+		})
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// findOrCreateTags returns the categories and tags with the given names, creating the missing ones
+func (s *VideoService) findOrCreateTags(categories, tags []string) ([]models.Tag, error) {
+	categoryTags, err := s.tagRepo.FindOrCreate(models.CategoryTag, NormalizeTagNames(categories))
+	if err != nil {
+		return nil, err
+	}
+	keywordTags, err := s.tagRepo.FindOrCreate(models.KeywordTag, NormalizeTagNames(tags))
+	if err != nil {
+		return nil, err
+	}
+	return append(categoryTags, keywordTags...), nil
+}
+
+// appendNewTags appends the categories and tags of video missing from seen to tags, marking them seen
+func appendNewTags(tags []models.Tag, seen map[string]bool, video *models.Video) []models.Tag {
+	for _, tag := range video.Tags {
+		key := tagScoresKey(tag)
+		if !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// GetTop10TrendingVideosByTag retrieves the top 10 of a category or tag leaderboard with editorial overrides applied
+func (s *VideoService) GetTop10TrendingVideosByTag(ctx context.Context, tag models.Tag) ([]map[string]interface{}, error) {
+	return s.getTop10(ctx, tagScoresKey(tag))
+}
+
+// NotifyTagTrending publishes the current top 10 of a category or tag
+func (s *VideoService) NotifyTagTrending(ctx context.Context, tag models.Tag) error {
+	trendingVideos, err := s.GetTop10TrendingVideosByTag(ctx, tag)
+	if err != nil {
+		log.Printf("Error getting top trending videos of %s %s: %v", tag.Kind, tag.Name, err)
+		return err
+	}
+
+	update := map[string]interface{}{
+		"type":           "trending_videos",
+		string(tag.Kind): tag.Name,
+		"videos":         trendingVideos,
+		"updated":        time.Now().Format(time.RFC3339),
+	}
+	jsonData, err := json.Marshal(update)
+	if err != nil {
+		log.Printf("Error preparing video data: %v", err)
+		return err
+	}
+	topic := ws.TagTopic(tag.Name)
+	if tag.Kind == models.CategoryTag {
+		topic = ws.CategoryTopic(tag.Name)
+	}
+	return SendNotification(s.redisClient, "trending_videos:"+string(tag.Kind)+":"+tag.Name, topic, string(jsonData))
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// countedTypes are the interaction types whose counts are kept in time buckets
+var countedTypes = []models.InteractionType{models.View, models.Like, models.Comment}
+
+// countKey returns the key of the counts of one interaction type in a time bucket
+func countKey(bucket string, eventType models.InteractionType) string {
+	return bucket + ":" + string(eventType)
+}
+
+// recordCounts adds the step of each event to the count of its type in the bucket bucketOf returns for the time
+// it happened, keeping every written bucket for ttl.
+// Counts rather than scores are bucketed so any scorer can be applied to the sum of a range of buckets.
+func recordCounts(ctx context.Context, redisClient *redis.Client, events []models.InteractionEvent, bucketOf func(time.Time) string, ttl time.Duration) error {
+	pipe := redisClient.TxPipeline()
+	keys := make(map[string]bool)
+	for _, event := range events {
+		if event.Step == 0 {
+			continue
+		}
+		at := event.CreatedAt
+		if at.IsZero() {
+			at = time.Now()
+		}
+		key := countKey(bucketOf(at), event.Type)
+		pipe.ZIncrBy(ctx, key, float64(event.Step), event.VideoID.String())
+		keys[key] = true
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	for key := range keys {
+		pipe.Expire(ctx, key, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// scoreBuckets sums the counts of buckets per interaction type and stores at dest the score scorer gives
+// every video counted in them, expiring after ttl. An empty sum deletes dest.
+// The scores are written to a temporary key and renamed so readers never see a partial set.
+func scoreBuckets(ctx context.Context, redisClient *redis.Client, scorer Scorer, buckets []string, dest string, ttl time.Duration) error {
+	tmp := dest + ":building:" + uuid.New().String()
+	pipe := redisClient.TxPipeline()
+	sums := make([]*redis.ZSliceCmd, len(countedTypes))
+	for i, eventType := range countedTypes {
+		keys := make([]string, len(buckets))
+		for j, bucket := range buckets {
+			keys[j] = countKey(bucket, eventType)
+		}
+		sumKey := countKey(tmp, eventType)
+		pipe.ZUnionStore(ctx, sumKey, &redis.ZStore{Keys: keys, Aggregate: "SUM"})
+		sums[i] = pipe.ZRangeWithScores(ctx, sumKey, 0, -1)
+		pipe.Del(ctx, sumKey)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	counts := make(map[string]*VideoCounters)
+	for i, eventType := range countedTypes {
+		for _, z := range sums[i].Val() {
+			videoID := z.Member.(string)
+			counters, ok := counts[videoID]
+			if !ok {
+				counters = &VideoCounters{}
+				counts[videoID] = counters
+			}
+			switch eventType {
+			case models.View:
+				counters.Views = int64(z.Score)
+			case models.Like:
+				counters.Likes = int64(z.Score)
+			case models.Comment:
+				counters.Comments = int64(z.Score)
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return redisClient.Del(ctx, dest).Err()
+	}
+
+	members := make([]*redis.Z, 0, len(counts))
+	for videoID, counters := range counts {
+		members = append(members, &redis.Z{
+			Score:  scorer.Score(counters.Views, counters.Likes, counters.Comments),
+			Member: videoID,
+		})
+	}
+	pipe = redisClient.TxPipeline()
+	for start := 0; start < len(members); start += rebuildBatchSize {
+		end := start + rebuildBatchSize
+		if end > len(members) {
+			end = len(members)
+		}
+		pipe.ZAdd(ctx, tmp, members[start:end]...)
+	}
+	pipe.Rename(ctx, tmp, dest)
+	pipe.Expire(ctx, dest, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
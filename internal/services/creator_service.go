@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/params/response"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// creatorScoresKey holds the sum of the video scores of every creator
+const creatorScoresKey = "creator:scores"
+
+// CreatorService handles business logic for the creator leaderboard
+type CreatorService struct {
+	userRepo    *repositories.UserRepository
+	videoRepo   *repositories.VideoRepository
+	redisClient *redis.Client
+}
+
+// NewCreatorService creates a new creator service
+func NewCreatorService(userRepo *repositories.UserRepository, videoRepo *repositories.VideoRepository, redisClient *redis.Client) *CreatorService {
+	return &CreatorService{
+		userRepo:    userRepo,
+		videoRepo:   videoRepo,
+		redisClient: redisClient,
+	}
+}
+
+// GetTrendingCreators retrieves a page of the creators with the highest aggregated score
+func (s *CreatorService) GetTrendingCreators(ctx context.Context, offset, limit int) ([]response.TrendingCreator, error) {
+	results, err := s.redisClient.ZRevRangeWithScores(ctx, creatorScoresKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(results))
+	for _, z := range results {
+		if id, err := uuid.Parse(z.Member.(string)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	users, err := s.userRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	creators := make([]response.TrendingCreator, 0, len(results))
+	for i, z := range results {
+		id, err := uuid.Parse(z.Member.(string))
+		if err != nil {
+			continue
+		}
+		user, ok := byID[id]
+		if !ok {
+			continue
+		}
+		creators = append(creators, response.TrendingCreator{
+			Rank:     offset + i + 1,
+			Score:    z.Score,
+			ID:       user.ID,
+			Username: user.Username,
+		})
+	}
+	return creators, nil
+}
+
+// GetCreatorVideos retrieves the videos of a creator, highest score first, with pagination
+func (s *CreatorService) GetCreatorVideos(userID uuid.UUID, page, pageSize int) ([]models.Video, error) {
+	offset := (page - 1) * pageSize
+	return s.videoRepo.FindByUser(userID, offset, pageSize)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// DeadLetterService stores interaction events that exhausted their retries and lets admins replay or discard them
+type DeadLetterService struct {
+	repo  *repositories.DeadLetterRepository
+	queue EventQueue
+}
+
+// NewDeadLetterService creates a new dead letter service
+func NewDeadLetterService(repo *repositories.DeadLetterRepository, queue EventQueue) *DeadLetterService {
+	return &DeadLetterService{
+		repo:  repo,
+		queue: queue,
+	}
+}
+
+// DeadLetter stores events that failed with err after attempts tries
+func (s *DeadLetterService) DeadLetter(events []models.InteractionEvent, err error, attempts int) error {
+	deadLetters := make([]models.DeadLetter, len(events))
+	for i, event := range events {
+		deadLetters[i] = models.NewDeadLetter(event, err, attempts)
+	}
+	return s.repo.CreateBatch(deadLetters)
+}
+
+// GetDeadLetter retrieves a dead-lettered event by ID
+func (s *DeadLetterService) GetDeadLetter(id uuid.UUID) (*models.DeadLetter, error) {
+	return s.repo.FindByID(id)
+}
+
+// ListDeadLetters retrieves a list of dead-lettered events with pagination
+func (s *DeadLetterService) ListDeadLetters(page, pageSize int) ([]models.DeadLetter, error) {
+	offset := (page - 1) * pageSize
+	return s.repo.List(offset, pageSize)
+}
+
+// ReplayDeadLetter puts a dead-lettered event back on the queue and removes it from the dead letters
+func (s *DeadLetterService) ReplayDeadLetter(ctx context.Context, id uuid.UUID) error {
+	deadLetter, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.queue.Enqueue(ctx, deadLetter.Event()); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// DiscardDeadLetter removes a dead-lettered event without applying it
+func (s *DeadLetterService) DiscardDeadLetter(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
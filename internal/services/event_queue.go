@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// Queue backends selectable with the QUEUE_BACKEND environment variable
+const (
+	MemoryQueueBackend = "memory"
+	RedisQueueBackend  = "redis"
+)
+
+const (
+	// streamEventField is the stream entry field holding the JSON encoded event
+	streamEventField = "event"
+	// streamBlock bounds how long a read waits for new entries so cancellation is noticed
+	streamBlock = 2 * time.Second
+)
+
+// Delivery is an event handed to a consumer. It must be acknowledged once processed.
+type Delivery struct {
+	ID    string
+	Event models.InteractionEvent
+}
+
+// EventQueue carries interaction events from the HTTP handlers to the queue consumer
+type EventQueue interface {
+	// Enqueue adds an event to the queue
+	Enqueue(ctx context.Context, event models.InteractionEvent) error
+	// EnqueueBatch adds events to the queue together
+	EnqueueBatch(ctx context.Context, events []models.InteractionEvent) error
+	// Dequeue blocks until at least one event is available or ctx is done and returns up to max events
+	Dequeue(ctx context.Context, max int) ([]Delivery, error)
+	// Ack marks deliveries as processed so they are not delivered again
+	Ack(ctx context.Context, deliveries ...Delivery) error
+	// Nack hands deliveries that could not be processed back to the queue so they are delivered again
+	Nack(ctx context.Context, deliveries ...Delivery) error
+	// Drain returns up to max events held in memory without waiting, so they can be processed before shutdown
+	Drain(ctx context.Context, max int) ([]Delivery, error)
+	// Durable reports whether unacknowledged events survive a restart
+	Durable() bool
+	// Len returns the number of events waiting to be processed
+	Len(ctx context.Context) (int64, error)
+}
+
+// MemoryQueue is an in-process EventQueue for development and tests.
+// Pending events are lost when the process exits.
+type MemoryQueue struct {
+	events chan models.InteractionEvent
+
+	// redelivered holds the events handed back with Nack, delivered before the buffered ones.
+	// They are kept apart from events since the consumer handing them back must never wait for room.
+	lock        sync.Mutex
+	redelivered []models.InteractionEvent
+	// wake interrupts a Dequeue waiting for events when some are handed back
+	wake chan struct{}
+}
+
+// NewMemoryQueue creates a new in-memory queue holding up to size events
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{
+		events: make(chan models.InteractionEvent, size),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Enqueue adds an event, waiting for room while the queue is full
+func (q *MemoryQueue) Enqueue(ctx context.Context, event models.InteractionEvent) error {
+	select {
+	case q.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// EnqueueBatch adds events one by one, waiting for room while the queue is full
+func (q *MemoryQueue) EnqueueBatch(ctx context.Context, events []models.InteractionEvent) error {
+	for _, event := range events {
+		if err := q.Enqueue(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dequeue waits for an event and returns it together with whatever else is already buffered, up to max
+func (q *MemoryQueue) Dequeue(ctx context.Context, max int) ([]Delivery, error) {
+	for {
+		if deliveries := q.takeRedelivered(max); len(deliveries) > 0 {
+			return deliveries, nil
+		}
+		select {
+		case event := <-q.events:
+			deliveries := []Delivery{{Event: event}}
+			for len(deliveries) < max {
+				select {
+				case event := <-q.events:
+					deliveries = append(deliveries, Delivery{Event: event})
+				default:
+					return deliveries, nil
+				}
+			}
+			return deliveries, nil
+		case <-q.wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Ack is a no-op since events leave the channel as soon as they are received
+func (q *MemoryQueue) Ack(ctx context.Context, deliveries ...Delivery) error {
+	return nil
+}
+
+// Nack keeps the events of deliveries to be returned by the next Dequeue or Drain
+func (q *MemoryQueue) Nack(ctx context.Context, deliveries ...Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	q.lock.Lock()
+	for _, delivery := range deliveries {
+		q.redelivered = append(q.redelivered, delivery.Event)
+	}
+	q.lock.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Drain returns up to max redelivered and buffered events without waiting
+func (q *MemoryQueue) Drain(ctx context.Context, max int) ([]Delivery, error) {
+	deliveries := q.takeRedelivered(max)
+	for len(deliveries) < max {
+		select {
+		case event := <-q.events:
+			deliveries = append(deliveries, Delivery{Event: event})
+		default:
+			return deliveries, nil
+		}
+	}
+	return deliveries, nil
+}
+
+// Durable is false since buffered events are lost when the process exits
+func (q *MemoryQueue) Durable() bool {
+	return false
+}
+
+// Len returns the number of buffered and redelivered events
+func (q *MemoryQueue) Len(ctx context.Context) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return int64(len(q.events) + len(q.redelivered)), nil
+}
+
+// takeRedelivered removes and returns up to max of the events handed back with Nack
+func (q *MemoryQueue) takeRedelivered(max int) []Delivery {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	n := len(q.redelivered)
+	if n > max {
+		n = max
+	}
+	deliveries := make([]Delivery, n)
+	for i, event := range q.redelivered[:n] {
+		deliveries[i] = Delivery{Event: event}
+	}
+	q.redelivered = q.redelivered[n:]
+	return deliveries
+}
+
+// RedisStreamQueue is an EventQueue backed by a Redis stream and consumer group.
+// Events survive restarts, and events delivered to a consumer that died before
+// acknowledging them are reclaimed by another consumer once they have been idle for claimIdle.
+type RedisStreamQueue struct {
+	redisClient *redis.Client
+	stream      string
+	group       string
+	consumer    string
+	maxLen      int64
+	claimIdle   time.Duration
+
+	lock      sync.Mutex
+	lastClaim time.Time
+	// recovering is set until the events left pending by a previous run of this consumer have been read again
+	recovering bool
+	// recoverFrom is the ID after which the previous run's pending events are read
+	recoverFrom string
+}
+
+// NewRedisStreamQueue creates a new stream queue.
+// consumer must be unique per process; maxLen caps the number of unprocessed events.
+func NewRedisStreamQueue(redisClient *redis.Client, stream, group, consumer string, maxLen int64, claimIdle time.Duration) *RedisStreamQueue {
+	return &RedisStreamQueue{
+		redisClient: redisClient,
+		stream:      stream,
+		group:       group,
+		consumer:    consumer,
+		maxLen:      maxLen,
+		claimIdle:   claimIdle,
+		recovering:  true,
+		recoverFrom: "0",
+	}
+}
+
+// Enqueue appends an event to the stream, or returns ErrQueueFull when maxLen events are waiting.
+// The stream is never trimmed since acknowledged events are already removed, so every entry is still unprocessed.
+func (q *RedisStreamQueue) Enqueue(ctx context.Context, event models.InteractionEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	length, err := q.redisClient.XLen(ctx, q.stream).Result()
+	if err != nil {
+		return err
+	}
+	if length >= q.maxLen {
+		return ErrQueueFull
+	}
+	return q.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.stream,
+		Values: map[string]interface{}{streamEventField: data},
+	}).Err()
+}
+
+// EnqueueBatch appends events to the stream in one pipeline, or returns ErrQueueFull when maxLen events are waiting.
+// The length is checked once for the whole batch so the stream may exceed maxLen by up to one batch.
+func (q *RedisStreamQueue) EnqueueBatch(ctx context.Context, events []models.InteractionEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	length, err := q.redisClient.XLen(ctx, q.stream).Result()
+	if err != nil {
+		return err
+	}
+	if length >= q.maxLen {
+		return ErrQueueFull
+	}
+	pipe := q.redisClient.Pipeline()
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.stream,
+			Values: map[string]interface{}{streamEventField: data},
+		})
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Dequeue first returns the events a previous run of this consumer left pending, then reclaimed events
+// of other consumers if any are due, otherwise waits for new events
+func (q *RedisStreamQueue) Dequeue(ctx context.Context, max int) ([]Delivery, error) {
+	for {
+		deliveries, err := q.recover(ctx, max)
+		if err != nil || len(deliveries) > 0 {
+			return deliveries, err
+		}
+		deliveries, err = q.reclaim(ctx, max)
+		if err != nil || len(deliveries) > 0 {
+			return deliveries, err
+		}
+
+		streams, err := q.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.group,
+			Consumer: q.consumer,
+			Streams:  []string{q.stream, ">"},
+			Count:    int64(max),
+			Block:    streamBlock,
+		}).Result()
+		if isNoGroup(err) {
+			if err := q.createGroup(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err == redis.Nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, stream := range streams {
+			deliveries = append(deliveries, q.decode(ctx, stream.Messages)...)
+		}
+		if len(deliveries) > 0 {
+			return deliveries, nil
+		}
+	}
+}
+
+// Ack acknowledges deliveries and removes them from the stream
+func (q *RedisStreamQueue) Ack(ctx context.Context, deliveries ...Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ids := make([]string, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.ID
+	}
+	pipe := q.redisClient.TxPipeline()
+	pipe.XAck(ctx, q.stream, q.group, ids...)
+	pipe.XDel(ctx, q.stream, ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Nack appends the events of deliveries to the stream again and acknowledges the original entries in one transaction.
+// Entries pending on this consumer are otherwise only read again after a restart.
+// maxLen is not enforced since the events were already admitted.
+func (q *RedisStreamQueue) Nack(ctx context.Context, deliveries ...Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	pipe := q.redisClient.TxPipeline()
+	ids := make([]string, len(deliveries))
+	for i, delivery := range deliveries {
+		data, err := json.Marshal(delivery.Event)
+		if err != nil {
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.stream,
+			Values: map[string]interface{}{streamEventField: data},
+		})
+		ids[i] = delivery.ID
+	}
+	pipe.XAck(ctx, q.stream, q.group, ids...)
+	pipe.XDel(ctx, q.stream, ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Drain returns nothing since unread events stay in the stream for the next start
+func (q *RedisStreamQueue) Drain(ctx context.Context, max int) ([]Delivery, error) {
+	return nil, nil
+}
+
+// Durable is true since unacknowledged events stay pending in the stream and are reclaimed
+func (q *RedisStreamQueue) Durable() bool {
+	return true
+}
+
+// Len returns the number of unread and unacknowledged events, since acknowledged events are removed from the stream
+func (q *RedisStreamQueue) Len(ctx context.Context) (int64, error) {
+	return q.redisClient.XLen(ctx, q.stream).Result()
+}
+
+// recover reads again the events delivered to this consumer by a previous run that never acknowledged them.
+// It reads them once, at start, since later this consumer's pending events are still being processed.
+func (q *RedisStreamQueue) recover(ctx context.Context, max int) ([]Delivery, error) {
+	if !q.recovering {
+		return nil, nil
+	}
+	streams, err := q.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.group,
+		Consumer: q.consumer,
+		Streams:  []string{q.stream, q.recoverFrom},
+		Count:    int64(max),
+		Block:    -1,
+	}).Result()
+	if isNoGroup(err) {
+		q.recovering = false
+		return nil, q.createGroup(ctx)
+	}
+	if err == redis.Nil {
+		q.recovering = false
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var messages []redis.XMessage
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+	if len(messages) == 0 {
+		q.recovering = false
+		return nil, nil
+	}
+	q.recoverFrom = messages[len(messages)-1].ID
+	log.Printf("Recovered %d interaction events left pending by a previous run", len(messages))
+	return q.decode(ctx, messages), nil
+}
+
+// reclaim takes over events that another consumer received but never acknowledged within claimIdle.
+// Events pending on this consumer are never reclaimed since they may still be waiting in a partition or a retry.
+// It runs at most once per half claimIdle.
+func (q *RedisStreamQueue) reclaim(ctx context.Context, max int) ([]Delivery, error) {
+	q.lock.Lock()
+	due := time.Since(q.lastClaim) >= q.claimIdle/2
+	if due {
+		q.lastClaim = time.Now()
+	}
+	q.lock.Unlock()
+	if !due {
+		return nil, nil
+	}
+
+	var ids []string
+	for start := "-"; len(ids) < max; {
+		pending, err := q.redisClient.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: q.stream,
+			Group:  q.group,
+			Idle:   q.claimIdle,
+			Start:  start,
+			End:    "+",
+			Count:  int64(max),
+		}).Result()
+		if isNoGroup(err) {
+			return nil, q.createGroup(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range pending {
+			if entry.Consumer != q.consumer && len(ids) < max {
+				ids = append(ids, entry.ID)
+			}
+		}
+		if len(pending) < max {
+			break
+		}
+		start = "(" + pending[len(pending)-1].ID
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// XCLAIM checks the idle time again so an event another consumer just claimed is skipped
+	messages, err := q.redisClient.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   q.stream,
+		Group:    q.group,
+		Consumer: q.consumer,
+		MinIdle:  q.claimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > 0 {
+		log.Printf("Reclaimed %d pending interaction events", len(messages))
+	}
+	return q.decode(ctx, messages), nil
+}
+
+// decode turns stream entries into deliveries. Malformed entries are acknowledged and skipped.
+func (q *RedisStreamQueue) decode(ctx context.Context, messages []redis.XMessage) []Delivery {
+	deliveries := make([]Delivery, 0, len(messages))
+	for _, message := range messages {
+		var event models.InteractionEvent
+		data, _ := message.Values[streamEventField].(string)
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			log.Printf("Discarding malformed interaction event %s: %v", message.ID, err)
+			if err := q.Ack(ctx, Delivery{ID: message.ID}); err != nil {
+				log.Printf("Error discarding interaction event %s: %v", message.ID, err)
+			}
+			continue
+		}
+		deliveries = append(deliveries, Delivery{ID: message.ID, Event: event})
+	}
+	return deliveries
+}
+
+// createGroup creates the consumer group, and the stream if needed, starting from the oldest entry
+func (q *RedisStreamQueue) createGroup(ctx context.Context) error {
+	err := q.redisClient.XGroupCreateMkStream(ctx, q.stream, q.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// isNoGroup reports whether err means the stream or its consumer group does not exist,
+// which happens on first start and after Redis loses its data
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
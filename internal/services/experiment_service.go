@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+	"github.com/trieuvy/video-ranking/internal/ws"
+	"gorm.io/gorm"
+)
+
+const (
+	// experimentPrefix prefixes the sorted sets and counters of every experiment arm
+	experimentPrefix = "experiment:"
+	// impressionsField counts how many times an arm's leaderboard was served
+	impressionsField = "impressions"
+	// backfillBatchSize is how many videos are scored per query when an experiment starts
+	backfillBatchSize = 500
+	// experimentStoppedChannel carries the ID of a running experiment that stopped to every instance
+	experimentStoppedChannel = "experiment:stopped"
+)
+
+// ErrInvalidExperiment is returned when an experiment cannot be created as requested
+var ErrInvalidExperiment = errors.New("invalid experiment")
+
+// ArmAssignment is the arm of the running experiment a user is bucketed into
+type ArmAssignment struct {
+	ExperimentID uuid.UUID
+	Arm          models.ExperimentArm
+}
+
+// Group returns the websocket group of the arm
+func (a *ArmAssignment) Group() string {
+	return a.ExperimentID.String() + ":" + a.Arm.Name
+}
+
+// ArmResult holds the evaluation counters of an experiment arm
+type ArmResult struct {
+	Arm      models.ExperimentArm `json:"arm"`
+	Counters map[string]int64     `json:"counters"`
+}
+
+// ExperimentService runs A/B experiments where each arm ranks videos with its own scorer
+type ExperimentService struct {
+	repo        *repositories.ExperimentRepository
+	videoRepo   *repositories.VideoRepository
+	redisClient *redis.Client
+	overrides   *OverrideService
+	cacheTTL    time.Duration
+
+	lock     sync.Mutex
+	active   *models.Experiment
+	cachedAt time.Time
+}
+
+// NewExperimentService creates a new experiment service.
+// The running experiment is cached for cacheTTL since it is read for every event and request.
+func NewExperimentService(repo *repositories.ExperimentRepository, videoRepo *repositories.VideoRepository, redisClient *redis.Client, overrides *OverrideService, cacheTTL time.Duration) *ExperimentService {
+	return &ExperimentService{
+		repo:        repo,
+		videoRepo:   videoRepo,
+		redisClient: redisClient,
+		overrides:   overrides,
+		cacheTTL:    cacheTTL,
+	}
+}
+
+// CreateExperiment saves a new, stopped experiment
+func (s *ExperimentService) CreateExperiment(experiment *models.Experiment) error {
+	if len(experiment.Arms) < 2 {
+		return fmt.Errorf("%w: at least 2 arms are required", ErrInvalidExperiment)
+	}
+	names := make(map[string]bool, len(experiment.Arms))
+	for _, arm := range experiment.Arms {
+		if names[arm.Name] {
+			return fmt.Errorf("%w: duplicate arm %q", ErrInvalidExperiment, arm.Name)
+		}
+		names[arm.Name] = true
+		if arm.Weight <= 0 {
+			return fmt.Errorf("%w: arm %q requires a weight of at least 1", ErrInvalidExperiment, arm.Name)
+		}
+		if _, err := NewScorer(arm.Scorer); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidExperiment, err)
+		}
+	}
+	experiment.Active = false
+	return s.repo.Create(experiment)
+}
+
+// GetExperiment retrieves an experiment by ID
+func (s *ExperimentService) GetExperiment(id uuid.UUID) (*models.Experiment, error) {
+	return s.repo.FindByID(id)
+}
+
+// ListExperiments retrieves a list of experiments with pagination
+func (s *ExperimentService) ListExperiments(page, pageSize int) ([]models.Experiment, error) {
+	offset := (page - 1) * pageSize
+	return s.repo.List(offset, pageSize)
+}
+
+// StartExperiment scores every video for each arm and makes the experiment the running one.
+// The experiment it replaces, if any, is stopped as with StopExperiment.
+func (s *ExperimentService) StartExperiment(ctx context.Context, id uuid.UUID) error {
+	experiment, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	previous, err := s.repo.FindActive()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		previous, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.backfill(ctx, experiment); err != nil {
+		return err
+	}
+	if err := s.repo.Start(id, time.Now()); err != nil {
+		return err
+	}
+	s.invalidate()
+	if previous != nil && previous.ID != id {
+		return s.redisClient.Publish(ctx, experimentStoppedChannel, previous.ID.String()).Err()
+	}
+	return nil
+}
+
+// StopExperiment stops an experiment.
+// When it was running, every instance is told to move its connected clients back to the default feed.
+func (s *ExperimentService) StopExperiment(ctx context.Context, id uuid.UUID) error {
+	experiment, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Stop(id, time.Now()); err != nil {
+		return err
+	}
+	s.invalidate()
+	if !experiment.Active {
+		return nil
+	}
+	return s.redisClient.Publish(ctx, experimentStoppedChannel, id.String()).Err()
+}
+
+// WatchStops moves the connected clients of this instance back to the default feed whenever a running experiment
+// stops, on any instance, until ctx is cancelled
+func (s *ExperimentService) WatchStops(ctx context.Context) {
+	pubsub := s.redisClient.Subscribe(ctx, experimentStoppedChannel)
+	defer pubsub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			log.Printf("Experiment %s stopped, moving clients back to the default feed", message.Payload)
+			s.invalidate()
+			ws.ClearGroups()
+		}
+	}
+}
+
+// GetResults retrieves the evaluation counters of every arm of an experiment
+func (s *ExperimentService) GetResults(ctx context.Context, id uuid.UUID) ([]ArmResult, error) {
+	experiment, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ArmResult, 0, len(experiment.Arms))
+	for _, arm := range experiment.Arms {
+		values, err := s.redisClient.HGetAll(ctx, armCountersKey(experiment.ID, arm.Name)).Result()
+		if err != nil {
+			return nil, err
+		}
+		counters := make(map[string]int64, len(values))
+		for field, value := range values {
+			counters[field], _ = strconv.ParseInt(value, 10, 64)
+		}
+		results = append(results, ArmResult{Arm: arm, Counters: counters})
+	}
+	return results, nil
+}
+
+// Assign returns the arm of the running experiment the user is deterministically bucketed into,
+// or nil when no experiment is running
+func (s *ExperimentService) Assign(userID uuid.UUID) (*ArmAssignment, error) {
+	if userID == uuid.Nil {
+		return nil, nil
+	}
+	experiment, err := s.ActiveExperiment()
+	if err != nil || experiment == nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, arm := range experiment.Arms {
+		total += arm.Weight
+	}
+	if total == 0 {
+		return nil, nil
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(experiment.ID.String() + ":" + userID.String()))
+	bucket := int(hash.Sum32() % uint32(total))
+	for _, arm := range experiment.Arms {
+		if bucket < arm.Weight {
+			return &ArmAssignment{ExperimentID: experiment.ID, Arm: arm}, nil
+		}
+		bucket -= arm.Weight
+	}
+	return nil, nil
+}
+
+// GroupOf returns the websocket group of a user, empty when they are not in an experiment
+func (s *ExperimentService) GroupOf(userID uuid.UUID) string {
+	assignment, err := s.Assign(userID)
+	if err != nil {
+		log.Printf("Error assigning experiment arm: %v", err)
+		return ""
+	}
+	if assignment == nil {
+		return ""
+	}
+	return assignment.Group()
+}
+
+// ActiveExperiment returns the running experiment, or nil when there is none
+func (s *ExperimentService) ActiveExperiment() (*models.Experiment, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if time.Since(s.cachedAt) <= s.cacheTTL {
+		return s.active, nil
+	}
+	experiment, err := s.repo.FindActive()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		experiment, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.active = experiment
+	s.cachedAt = time.Now()
+	return experiment, nil
+}
+
+// RecordEvents rescores the videos of a batch of events for every arm, counts the engagement of each user's arm
+// and broadcasts each arm's leaderboard to its clients
+func (s *ExperimentService) RecordEvents(ctx context.Context, events []models.InteractionEvent) error {
+	experiment, err := s.ActiveExperiment()
+	if err != nil || experiment == nil {
+		return err
+	}
+	seen := make(map[uuid.UUID]bool, len(events))
+	var ids []uuid.UUID
+	for _, event := range events {
+		if !seen[event.VideoID] {
+			seen[event.VideoID] = true
+			ids = append(ids, event.VideoID)
+		}
+	}
+	videos, err := s.videoRepo.FindByIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	for _, arm := range experiment.Arms {
+		scorer, err := NewScorer(arm.Scorer)
+		if err != nil {
+			return err
+		}
+		for _, video := range videos {
+			pipe.ZAdd(ctx, armScoresKey(experiment.ID, arm.Name), &redis.Z{
+				Score:  scorer.Score(video.Views, video.Likes, video.Comments),
+				Member: video.ID.String(),
+			})
+		}
+	}
+	for _, event := range events {
+		assignment, err := s.Assign(event.UserID)
+		if err != nil {
+			return err
+		}
+		if assignment != nil {
+			pipe.HIncrBy(ctx, armCountersKey(experiment.ID, assignment.Arm.Name), string(event.Type), int64(event.Step))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	for _, arm := range experiment.Arms {
+		if err := s.notifyArm(ctx, experiment.ID, arm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArmScores returns a page of an arm's leaderboard with editorial overrides applied and counts it as an impression
+func (s *ExperimentService) ArmScores(ctx context.Context, assignment *ArmAssignment, offset, limit int) ([]RankedVideo, error) {
+	key := armScoresKey(assignment.ExperimentID, assignment.Arm.Name)
+	raw, err := s.redisClient.ZRevRangeWithScores(ctx, key, 0, int64(offset+limit+trendingCandidates-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	ranked, err := s.overrides.Apply(ctx, key, raw, offset+limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redisClient.HIncrBy(ctx, armCountersKey(assignment.ExperimentID, assignment.Arm.Name), impressionsField, 1).Err(); err != nil {
+		log.Printf("Error counting experiment impression: %v", err)
+	}
+	if offset >= len(ranked) {
+		return nil, nil
+	}
+	return ranked[offset:], nil
+}
+
+// NotifyArms publishes the top 10 of each arm of the running experiment to the clients assigned to it
+func (s *ExperimentService) NotifyArms(ctx context.Context) error {
+	experiment, err := s.ActiveExperiment()
+	if err != nil || experiment == nil {
+		return err
+	}
+	for _, arm := range experiment.Arms {
+		if err := s.notifyArm(ctx, experiment.ID, arm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyArm publishes the top 10 of an arm with editorial overrides applied to the clients assigned to it
+func (s *ExperimentService) notifyArm(ctx context.Context, experimentID uuid.UUID, arm models.ExperimentArm) error {
+	key := armScoresKey(experimentID, arm.Name)
+	results, err := s.redisClient.ZRevRangeWithScores(ctx, key, 0, trendingCandidates-1).Result()
+	if err != nil {
+		return err
+	}
+	ranked, err := s.overrides.Apply(ctx, key, results, 10)
+	if err != nil {
+		return err
+	}
+	trendingVideos := rankedVideoData(ranked)
+
+	update := map[string]interface{}{
+		"type":       "trending_videos",
+		"experiment": experimentID.String(),
+		"arm":        arm.Name,
+		"videos":     trendingVideos,
+		"updated":    time.Now().Format(time.RFC3339),
+	}
+	jsonData, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	assignment := ArmAssignment{ExperimentID: experimentID, Arm: arm}
+	return SendGroupNotification(s.redisClient, "trending_videos:experiment:"+assignment.Group(), ws.TrendingTopic, assignment.Group(), string(jsonData))
+}
+
+// backfill scores every existing video for each arm of an experiment
+func (s *ExperimentService) backfill(ctx context.Context, experiment *models.Experiment) error {
+	scorers := make([]Scorer, len(experiment.Arms))
+	for i, arm := range experiment.Arms {
+		scorer, err := NewScorer(arm.Scorer)
+		if err != nil {
+			return err
+		}
+		scorers[i] = scorer
+	}
+
+	for offset := 0; ; offset += backfillBatchSize {
+		videos, err := s.videoRepo.List(offset, backfillBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(videos) == 0 {
+			return nil
+		}
+		pipe := s.redisClient.Pipeline()
+		for i, arm := range experiment.Arms {
+			members := make([]*redis.Z, len(videos))
+			for j, video := range videos {
+				members[j] = &redis.Z{
+					Score:  scorers[i].Score(video.Views, video.Likes, video.Comments),
+					Member: video.ID.String(),
+				}
+			}
+			pipe.ZAdd(ctx, armScoresKey(experiment.ID, arm.Name), members...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// invalidate drops the cached running experiment so the next read reloads it
+func (s *ExperimentService) invalidate() {
+	s.lock.Lock()
+	s.cachedAt = time.Time{}
+	s.lock.Unlock()
+}
+
+// armScoresKey returns the key of the leaderboard of an experiment arm
+func armScoresKey(experimentID uuid.UUID, arm string) string {
+	return experimentPrefix + experimentID.String() + ":arm:" + arm + ":scores"
+}
+
+// armCountersKey returns the key of the evaluation counters of an experiment arm
+func armCountersKey(experimentID uuid.UUID, arm string) string {
+	return experimentPrefix + experimentID.String() + ":arm:" + arm + ":counters"
+}
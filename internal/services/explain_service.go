@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+// RankingExplanation breaks down how a video obtained its score and rank
+type RankingExplanation struct {
+	VideoID      uuid.UUID                `json:"video_id"`
+	Scoring      ScoreExplanation         `json:"scoring"`
+	StoredScore  float64                  `json:"stored_score"`
+	Decay        HotExplanation           `json:"decay"`
+	Overrides    []models.RankingOverride `json:"overrides"`
+	Blacklisted  bool                     `json:"blacklisted"`
+	Multiplier   float64                  `json:"multiplier"`
+	PinnedAt     *int                     `json:"pinned_at"`
+	FinalScore   float64                  `json:"final_score"`
+	RawRank      *int64                   `json:"raw_rank"`
+	TrendingRank *int                     `json:"trending_rank"`
+}
+
+// ExplainService explains rankings using the same scorer, decay and overrides as the live leaderboard
+type ExplainService struct {
+	videoService      *VideoService
+	hotRankingService *HotRankingService
+	overrideService   *OverrideService
+	redisClient       *redis.Client
+}
+
+// NewExplainService creates a new explain service
+func NewExplainService(videoService *VideoService, hotRankingService *HotRankingService, overrideService *OverrideService, redisClient *redis.Client) *ExplainService {
+	return &ExplainService{
+		videoService:      videoService,
+		hotRankingService: hotRankingService,
+		overrideService:   overrideService,
+		redisClient:       redisClient,
+	}
+}
+
+// ExplainRanking explains the score and rank of a video
+func (s *ExplainService) ExplainRanking(ctx context.Context, videoID uuid.UUID) (*RankingExplanation, error) {
+	video, err := s.videoService.GetVideo(videoID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	scoring := s.videoService.Scorer().Explain(video.Views, video.Likes, video.Comments)
+	explanation := &RankingExplanation{
+		VideoID:     videoID,
+		Scoring:     scoring,
+		StoredScore: video.Score,
+		Decay:       s.hotRankingService.Explain(video, now),
+		Overrides:   []models.RankingOverride{},
+		Multiplier:  1,
+		FinalScore:  scoring.Score,
+	}
+
+	active, err := s.overrideService.ActiveOverrides()
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range active {
+		if override.VideoID == videoID {
+			explanation.Overrides = append(explanation.Overrides, override)
+		}
+	}
+	blacklisted, multipliers, positions := summarizeOverrides(explanation.Overrides)
+	explanation.Blacklisted = blacklisted[videoID.String()]
+	if multiplier, ok := multipliers[videoID.String()]; ok {
+		explanation.Multiplier = multiplier
+		explanation.FinalScore = scoring.Score * multiplier
+	}
+	if position, ok := positions[videoID.String()]; ok {
+		explanation.PinnedAt = &position
+	}
+
+	rank, err := s.redisClient.ZRevRank(ctx, videoScoresKey, videoID.String()).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if err == nil {
+		rank++
+		explanation.RawRank = &rank
+	}
+
+	trendingVideos, err := s.videoService.GetTop10TrendingVideos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, videoData := range trendingVideos {
+		if videoData["video_id"] == videoID.String() {
+			trendingRank := videoData["rank"].(int)
+			explanation.TrendingRank = &trendingRank
+			break
+		}
+	}
+	return explanation, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+const (
+	// hotScoresKey holds the time-decayed score of every active video
+	hotScoresKey = "video:hot"
+	// activeVideosKey holds the unix time of the last interaction of each video
+	activeVideosKey = "video:active"
+)
+
+// HotRankingService maintains the time-decayed "hot" leaderboard
+type HotRankingService struct {
+	repo         *repositories.VideoRepository
+	redisClient  *redis.Client
+	scorer       Scorer
+	gravity      float64
+	activeWindow time.Duration
+}
+
+// NewHotRankingService creates a new hot ranking service.
+// Videos without interactions during activeWindow are dropped from the hot leaderboard.
+func NewHotRankingService(repo *repositories.VideoRepository, redisClient *redis.Client, scorer Scorer, gravity float64, activeWindow time.Duration) *HotRankingService {
+	return &HotRankingService{
+		repo:         repo,
+		redisClient:  redisClient,
+		scorer:       scorer,
+		gravity:      gravity,
+		activeWindow: activeWindow,
+	}
+}
+
+// HotScore decays an engagement score by the age of the video, Hacker News style
+func HotScore(engagement float64, age time.Duration, gravity float64) float64 {
+	hours := math.Max(age.Hours(), 0)
+	return engagement / math.Pow(hours+2, gravity)
+}
+
+// HotExplanation describes how the hot score of a video is derived from its engagement score
+type HotExplanation struct {
+	Gravity    float64 `json:"gravity"`
+	AgeHours   float64 `json:"age_hours"`
+	Engagement float64 `json:"engagement"`
+	Score      float64 `json:"score"`
+}
+
+// Explain computes the hot score of a video at now
+func (s *HotRankingService) Explain(video *models.Video, now time.Time) HotExplanation {
+	age := now.Sub(video.CreatedAt)
+	engagement := s.scorer.Score(video.Views, video.Likes, video.Comments)
+	return HotExplanation{
+		Gravity:    s.gravity,
+		AgeHours:   age.Hours(),
+		Engagement: engagement,
+		Score:      HotScore(engagement, age, s.gravity),
+	}
+}
+
+// Run recomputes the hot leaderboard every interval until ctx is cancelled
+func (s *HotRankingService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Recompute(ctx); err != nil {
+				log.Printf("Error recomputing hot ranking: %v", err)
+			}
+		}
+	}
+}
+
+// Recompute refreshes the hot score of every active video and evicts inactive ones
+func (s *HotRankingService) Recompute(ctx context.Context) error {
+	now := time.Now()
+	cutoff := strconv.FormatInt(now.Add(-s.activeWindow).Unix(), 10)
+
+	stale, err := s.redisClient.ZRangeByScore(ctx, activeVideosKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + cutoff,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		pipe := s.redisClient.TxPipeline()
+		pipe.ZRem(ctx, hotScoresKey, toMembers(stale)...)
+		pipe.ZRem(ctx, activeVideosKey, toMembers(stale)...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	members, err := s.redisClient.ZRange(ctx, activeVideosKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	ids := parseVideoIDs(members)
+	if len(ids) == 0 {
+		return nil
+	}
+	videos, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.redisClient.Pipeline()
+	for i := range videos {
+		pipe.ZAdd(ctx, hotScoresKey, &redis.Z{
+			Score:  s.Explain(&videos[i], now).Score,
+			Member: videos[i].ID.String(),
+		})
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// TopScores returns a page of the videos with the highest hot score
+func (s *HotRankingService) TopScores(ctx context.Context, offset, limit int) ([]redis.Z, error) {
+	return s.redisClient.ZRevRangeWithScores(ctx, hotScoresKey, int64(offset), int64(offset+limit-1)).Result()
+}
+
+// toMembers converts string members into the variadic form expected by ZRem
+func toMembers(values []string) []interface{} {
+	members := make([]interface{}, len(values))
+	for i, v := range values {
+		members[i] = v
+	}
+	return members
+}
+
+// parseVideoIDs parses sorted set members into video IDs, skipping malformed ones
+func parseVideoIDs(members []string) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		id, err := uuid.Parse(member)
+		if err != nil {
+			log.Printf("Skipping invalid video ID %q in Redis: %v", member, err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// idempotencyPrefix prefixes the stored responses of idempotent requests
+	idempotencyPrefix = "idempotency:"
+	// idempotencyPending marks a key whose first request is still being handled
+	idempotencyPending = "pending"
+)
+
+var (
+	// ErrIdempotencyInProgress is returned while the first request with the same key is still being handled
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrIdempotencyKeyReused is returned when a key is reused with a different request body
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+)
+
+// StoredResponse is the response of the first request made with an idempotency key
+type StoredResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// IdempotencyService remembers the responses of requests carrying an idempotency key
+// so retries return the original response instead of being handled again
+type IdempotencyService struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	pendingTTL  time.Duration
+}
+
+// NewIdempotencyService creates a new idempotency service.
+// Responses are kept for ttl; a key stays locked for at most pendingTTL while its first request is handled.
+func NewIdempotencyService(redisClient *redis.Client, ttl, pendingTTL time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		redisClient: redisClient,
+		ttl:         ttl,
+		pendingTTL:  pendingTTL,
+	}
+}
+
+// Begin claims a key for a request identified by fingerprint.
+// It returns the stored response when the key was already used by the same request, nil when the caller
+// must handle the request and then Complete or Abort the key.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (*StoredResponse, error) {
+	redisKey := idempotencyKey(scope, key)
+	claimed, err := s.redisClient.SetNX(ctx, redisKey, idempotencyPending, s.pendingTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	value, err := s.redisClient.Get(ctx, redisKey).Result()
+	if err == redis.Nil {
+		// The key expired in the meantime, try again
+		return s.Begin(ctx, scope, key, fingerprint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value == idempotencyPending {
+		return nil, ErrIdempotencyInProgress
+	}
+	var response StoredResponse
+	if err := json.Unmarshal([]byte(value), &response); err != nil {
+		return nil, err
+	}
+	if response.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return &response, nil
+}
+
+// Complete stores the response of the request that claimed a key
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key string, response StoredResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return s.redisClient.Set(ctx, idempotencyKey(scope, key), data, s.ttl).Err()
+}
+
+// Abort releases a key so the request can be retried, used when handling failed transiently
+func (s *IdempotencyService) Abort(ctx context.Context, scope, key string) error {
+	return s.redisClient.Del(ctx, idempotencyKey(scope, key)).Err()
+}
+
+// idempotencyKey returns the key of the stored response of a request
+func idempotencyKey(scope, key string) string {
+	return idempotencyPrefix + scope + ":" + key
+}
@@ -15,9 +15,9 @@ func NewInteractionService(repo *repositories.InteractionRepository) *Interactio
 	return &InteractionService{repo: repo}
 }
 
-// CreateInteraction creates a new interaction and processes it
+// CreateInteraction creates a new interaction together with the outbox event that will update the video counters
 func (s *InteractionService) CreateInteraction(interaction *models.Interaction) error {
-	return s.repo.Create(interaction)
+	return s.repo.CreateWithOutbox(interaction, models.NewOutboxEvent(interaction, 1))
 }
 
 // GetInteraction retrieves an interaction by ID
@@ -35,9 +35,9 @@ func (s *InteractionService) UpdateInteraction(interaction *models.Interaction)
 	return s.repo.Update(interaction)
 }
 
-// DeleteInteraction removes an interaction
-func (s *InteractionService) DeleteInteraction(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// DeleteInteraction removes an interaction and writes the outbox event that will update the video counters
+func (s *InteractionService) DeleteInteraction(interaction *models.Interaction) error {
+	return s.repo.DeleteWithOutbox(interaction.ID, models.NewOutboxEvent(interaction, -1))
 }
 
 // ListInteractions retrieves a list of interactions with pagination
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// ErrLockHeld is returned when another instance already holds a maintenance lock
+var ErrLockHeld = errors.New("another instance is already running this job")
+
+// releaseLockScript deletes a lock only while it still holds the caller's token,
+// so a lock that expired and was taken over by another instance is left alone
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// acquireLock takes the lock stored at key for at most ttl so a single instance runs a job at a time.
+// It returns ErrLockHeld when the lock is taken, otherwise a function releasing it.
+func acquireLock(ctx context.Context, redisClient *redis.Client, key string, ttl time.Duration) (func(), error) {
+	token := uuid.New().String()
+	acquired, err := redisClient.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrLockHeld
+	}
+	return func() {
+		// Releasing is not cancelled so the lock does not outlive the job until its TTL
+		if err := releaseLockScript.Run(context.Background(), redisClient, []string{key}, token).Err(); err != nil {
+			log.Printf("Error releasing lock %s: %v", key, err)
+		}
+	}, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// OutboxRelay publishes the interaction events written to the outbox to the interaction queue.
+// A relay claims a batch of events, publishes them and marks them delivered; a claim left by a relay that
+// crashed expires after claimTTL. Delivery is at least once: an event published right before a crash, or
+// before a failed publish of its batch, is relayed again. Events carry their outbox ID so the consumer applies
+// each one once.
+type OutboxRelay struct {
+	repo               *repositories.OutboxRepository
+	queue              EventQueue
+	batchSize          int
+	claimTTL           time.Duration
+	retention          time.Duration
+	processedRetention time.Duration
+
+	lock      sync.Mutex
+	backlog   int64
+	countedAt time.Time
+}
+
+// NewOutboxRelay creates a new outbox relay relaying up to batchSize events at a time.
+// Delivered events are kept for retention and the records of processed events for processedRetention,
+// which is raised to retention if shorter since a delivered event may still be redelivered by the queue.
+func NewOutboxRelay(repo *repositories.OutboxRepository, queue EventQueue, batchSize int, claimTTL, retention, processedRetention time.Duration) *OutboxRelay {
+	if processedRetention < retention {
+		processedRetention = retention
+	}
+	return &OutboxRelay{
+		repo:               repo,
+		queue:              queue,
+		batchSize:          batchSize,
+		claimTTL:           claimTTL,
+		retention:          retention,
+		processedRetention: processedRetention,
+	}
+}
+
+// Run relays pending events every interval until ctx is cancelled
+func (s *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Relay(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error relaying outbox events: %v", err)
+		}
+		if err := s.repo.DeleteDeliveredBefore(time.Now().Add(-s.retention)); err != nil {
+			log.Printf("Error removing delivered outbox events: %v", err)
+		}
+		if err := s.repo.DeleteProcessedBefore(time.Now().Add(-s.processedRetention)); err != nil {
+			log.Printf("Error removing processed event records: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Backlog returns the number of events waiting to be relayed.
+// It is counted at most once per backpressurePoll since every request waiting for room in the queue reads it.
+func (s *OutboxRelay) Backlog(ctx context.Context) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if time.Since(s.countedAt) < backpressurePoll {
+		return s.backlog, nil
+	}
+	backlog, err := s.repo.CountUndelivered()
+	if err != nil {
+		return 0, err
+	}
+	s.backlog = backlog
+	s.countedAt = time.Now()
+	return backlog, nil
+}
+
+// Relay enqueues pending events batch by batch until none are left
+func (s *OutboxRelay) Relay(ctx context.Context) error {
+	for ctx.Err() == nil {
+		claimed, err := s.repo.ClaimBatch(s.batchSize, s.claimTTL)
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+		events := make([]models.InteractionEvent, len(claimed))
+		for i, event := range claimed {
+			events[i] = event.Event()
+		}
+		if err := s.queue.EnqueueBatch(ctx, events); err != nil {
+			if err := s.repo.Release(claimed); err != nil {
+				log.Printf("Error releasing %d outbox events: %v", len(claimed), err)
+			}
+			return err
+		}
+		if err := s.repo.MarkDelivered(claimed); err != nil {
+			return err
+		}
+		if len(claimed) < s.batchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// ErrInvalidOverride is returned when an override's parameters do not match its action
+var ErrInvalidOverride = errors.New("invalid ranking override")
+
+// RankedVideo is a leaderboard entry after editorial overrides have been applied
+type RankedVideo struct {
+	VideoID    string
+	Score      float64
+	RawScore   float64
+	Multiplier float64
+	Pinned     bool
+}
+
+// OverrideService manages editorial ranking overrides and applies them to leaderboards
+type OverrideService struct {
+	repo        *repositories.OverrideRepository
+	redisClient *redis.Client
+	cacheTTL    time.Duration
+
+	lock     sync.Mutex
+	cached   []models.RankingOverride
+	cachedAt time.Time
+}
+
+// NewOverrideService creates a new override service.
+// Active overrides are cached for cacheTTL since they are read on every broadcast.
+func NewOverrideService(repo *repositories.OverrideRepository, redisClient *redis.Client, cacheTTL time.Duration) *OverrideService {
+	return &OverrideService{
+		repo:        repo,
+		redisClient: redisClient,
+		cacheTTL:    cacheTTL,
+	}
+}
+
+// CreateOverride validates and saves a new override
+func (s *OverrideService) CreateOverride(override *models.RankingOverride) error {
+	switch override.Action {
+	case models.Pin:
+		if override.Position < 1 {
+			return fmt.Errorf("%w: pin requires a position of at least 1", ErrInvalidOverride)
+		}
+		override.Multiplier = 0
+	case models.Boost:
+		if override.Multiplier <= 1 {
+			return fmt.Errorf("%w: boost requires a multiplier greater than 1", ErrInvalidOverride)
+		}
+		override.Position = 0
+	case models.Demote:
+		if override.Multiplier <= 0 || override.Multiplier >= 1 {
+			return fmt.Errorf("%w: demote requires a multiplier between 0 and 1", ErrInvalidOverride)
+		}
+		override.Position = 0
+	case models.Blacklist:
+		override.Position = 0
+		override.Multiplier = 0
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidOverride, override.Action)
+	}
+	if override.ExpiresAt != nil && !override.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidOverride)
+	}
+
+	if err := s.repo.Create(override); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// GetOverride retrieves an override by ID
+func (s *OverrideService) GetOverride(id uuid.UUID) (*models.RankingOverride, error) {
+	return s.repo.FindByID(id)
+}
+
+// DeleteOverride removes an override
+func (s *OverrideService) DeleteOverride(id uuid.UUID) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// ListOverrides retrieves a list of overrides with pagination
+func (s *OverrideService) ListOverrides(page, pageSize int) ([]models.RankingOverride, error) {
+	offset := (page - 1) * pageSize
+	return s.repo.List(offset, pageSize)
+}
+
+// ActiveOverrides returns the overrides that have not expired yet
+func (s *OverrideService) ActiveOverrides() ([]models.RankingOverride, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if s.cached == nil || now.Sub(s.cachedAt) > s.cacheTTL {
+		overrides, err := s.repo.FindActive(now)
+		if err != nil {
+			return nil, err
+		}
+		if overrides == nil {
+			overrides = []models.RankingOverride{}
+		}
+		s.cached = overrides
+		s.cachedAt = now
+	}
+
+	active := make([]models.RankingOverride, 0, len(s.cached))
+	for _, override := range s.cached {
+		if override.IsActive(now) {
+			active = append(active, override)
+		}
+	}
+	return active, nil
+}
+
+// ExpiredBetween returns the overrides that expired after from and no later than to
+func (s *OverrideService) ExpiredBetween(from, to time.Time) ([]models.RankingOverride, error) {
+	return s.repo.FindExpiredBetween(from, to)
+}
+
+// Apply adjusts the raw entries of the leaderboard stored at key with the active overrides and returns the first limit entries.
+// Blacklisted videos are removed, boosts and demotions multiply the score and pinned videos are
+// placed at their position regardless of their score.
+func (s *OverrideService) Apply(ctx context.Context, key string, raw []redis.Z, limit int) ([]RankedVideo, error) {
+	overrides, err := s.ActiveOverrides()
+	if err != nil {
+		return nil, err
+	}
+
+	blacklisted, multipliers, positions := summarizeOverrides(overrides)
+
+	rawScores := make(map[string]float64, len(raw))
+	for _, z := range raw {
+		rawScores[z.Member.(string)] = z.Score
+	}
+	// Boosted or pinned videos may sit below the fetched page or not belong to this leaderboard at all
+	missing := make(map[string]bool)
+	for videoID := range multipliers {
+		missing[videoID] = true
+	}
+	for videoID := range positions {
+		missing[videoID] = true
+	}
+	for videoID := range missing {
+		if _, ok := rawScores[videoID]; ok || blacklisted[videoID] {
+			continue
+		}
+		score, err := s.redisClient.ZScore(ctx, key, videoID).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rawScores[videoID] = score
+	}
+
+	var pinned, ranked []RankedVideo
+	for videoID, rawScore := range rawScores {
+		if blacklisted[videoID] {
+			continue
+		}
+		multiplier, ok := multipliers[videoID]
+		if !ok {
+			multiplier = 1
+		}
+		entry := RankedVideo{
+			VideoID:    videoID,
+			Score:      rawScore * multiplier,
+			RawScore:   rawScore,
+			Multiplier: multiplier,
+		}
+		if _, ok := positions[videoID]; ok {
+			entry.Pinned = true
+			pinned = append(pinned, entry)
+			continue
+		}
+		ranked = append(ranked, entry)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].VideoID > ranked[j].VideoID
+	})
+	sort.Slice(pinned, func(i, j int) bool {
+		return positions[pinned[i].VideoID] < positions[pinned[j].VideoID]
+	})
+
+	result := make([]RankedVideo, 0, limit)
+	pi, ri := 0, 0
+	for len(result) < limit {
+		if pi < len(pinned) && (positions[pinned[pi].VideoID] <= len(result)+1 || ri >= len(ranked)) {
+			if positions[pinned[pi].VideoID] > limit {
+				break
+			}
+			result = append(result, pinned[pi])
+			pi++
+			continue
+		}
+		if ri < len(ranked) {
+			result = append(result, ranked[ri])
+			ri++
+			continue
+		}
+		break
+	}
+	return result, nil
+}
+
+// summarizeOverrides folds overrides into the blacklisted videos, the combined score multiplier
+// and the pinned position of each video
+func summarizeOverrides(overrides []models.RankingOverride) (map[string]bool, map[string]float64, map[string]int) {
+	blacklisted := make(map[string]bool)
+	multipliers := make(map[string]float64)
+	positions := make(map[string]int)
+	for _, override := range overrides {
+		videoID := override.VideoID.String()
+		switch override.Action {
+		case models.Blacklist:
+			blacklisted[videoID] = true
+		case models.Boost, models.Demote:
+			if _, ok := multipliers[videoID]; !ok {
+				multipliers[videoID] = 1
+			}
+			multipliers[videoID] *= override.Multiplier
+		case models.Pin:
+			// Overrides are ordered oldest first so the latest pin wins
+			positions[videoID] = override.Position
+		}
+	}
+	return blacklisted, multipliers, positions
+}
+
+// invalidate drops the cached active overrides so the next read reloads them
+func (s *OverrideService) invalidate() {
+	s.lock.Lock()
+	s.cached = nil
+	s.lock.Unlock()
+}
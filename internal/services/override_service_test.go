@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+func TestOverrideServiceApply(t *testing.T) {
+	a, b, c, d := uuid.UUID{15: 'a'}, uuid.UUID{15: 'b'}, uuid.UUID{15: 'c'}, uuid.UUID{15: 'd'}
+	raw := []redis.Z{
+		{Member: a.String(), Score: 40},
+		{Member: b.String(), Score: 30},
+		{Member: c.String(), Score: 20},
+		{Member: d.String(), Score: 10},
+	}
+	expired := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		overrides []models.RankingOverride
+		want      []uuid.UUID
+		wantScore float64
+	}{
+		{
+			name:      "no overrides keeps the raw order",
+			want:      []uuid.UUID{a, b, c},
+			wantScore: 40,
+		},
+		{
+			name:      "blacklisted video is removed",
+			overrides: []models.RankingOverride{{VideoID: a, Action: models.Blacklist}},
+			want:      []uuid.UUID{b, c, d},
+			wantScore: 30,
+		},
+		{
+			name: "boost and demote multiply the score",
+			overrides: []models.RankingOverride{
+				{VideoID: d, Action: models.Boost, Multiplier: 10},
+				{VideoID: d, Action: models.Demote, Multiplier: 0.5},
+			},
+			want:      []uuid.UUID{d, a, b},
+			wantScore: 50,
+		},
+		{
+			name:      "pinned video takes its position",
+			overrides: []models.RankingOverride{{VideoID: d, Action: models.Pin, Position: 2}},
+			want:      []uuid.UUID{a, d, b},
+			wantScore: 40,
+		},
+		{
+			name:      "pin below the limit is left out",
+			overrides: []models.RankingOverride{{VideoID: a, Action: models.Pin, Position: 4}},
+			want:      []uuid.UUID{b, c, d},
+			wantScore: 30,
+		},
+		{
+			name: "blacklist wins over pin",
+			overrides: []models.RankingOverride{
+				{VideoID: d, Action: models.Pin, Position: 1},
+				{VideoID: d, Action: models.Blacklist},
+			},
+			want:      []uuid.UUID{a, b, c},
+			wantScore: 40,
+		},
+		{
+			name:      "expired override is ignored",
+			overrides: []models.RankingOverride{{VideoID: a, Action: models.Blacklist, ExpiresAt: &expired}},
+			want:      []uuid.UUID{a, b, c},
+			wantScore: 40,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every overridden video is part of raw so Apply never needs to look up a missing score
+			service := &OverrideService{cacheTTL: time.Hour, cached: append([]models.RankingOverride{}, tt.overrides...), cachedAt: time.Now()}
+			got, err := service.Apply(context.Background(), videoScoresKey, raw, 3)
+			if err != nil {
+				t.Fatalf("Apply returned error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply returned %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, entry := range got {
+				if entry.VideoID != tt.want[i].String() {
+					t.Errorf("entry %d is %s, want %s", i, entry.VideoID, tt.want[i])
+				}
+			}
+			if got[0].Score != tt.wantScore {
+				t.Errorf("top score = %v, want %v", got[0].Score, tt.wantScore)
+			}
+		})
+	}
+}
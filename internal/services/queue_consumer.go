@@ -1,19 +1,249 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/trieuvy/video-ranking/internal/models"
 )
 
-// processEvent processes a single event from the queue
-func QueueConsumer(queue chan models.InteractionEvent, QueueServices *QueueServices) {
+// errSpilled is recorded on the events of a non-durable queue that were still pending when the shutdown deadline passed
+var errSpilled = errors.New("spilled on shutdown before being processed")
+
+// DrainReport tells what happened to the events still in flight when the consumer was shut down
+type DrainReport struct {
+	// Processed counts the events processed after intake stopped
+	Processed int64
+	// Spilled counts the events left unprocessed, either to be redelivered by a durable queue or dead-lettered
+	Spilled int64
+}
+
+// QueueConsumer processes events from the queue with a pool of workers.
+// Events are partitioned by video ID so the events of a video are always processed in order by the same worker.
+// Each worker coalesces its events for up to flushInterval, or until batchSize events are pending, and processes them together.
+// Events are acknowledged only after they have been processed, so a crash redelivers them.
+type QueueConsumer struct {
+	queueServices *QueueServices
+	workers       int
+	flushInterval time.Duration
+	batchSize     int
+
+	// intake is cancelled to stop reading new events from the queue
+	intake     context.Context
+	stopIntake context.CancelFunc
+	// processing is cancelled when the shutdown deadline passes to spill whatever is still pending
+	processing      context.Context
+	abortProcessing context.CancelFunc
+	done            chan struct{}
+
+	processed int64
+	spilled   int64
+}
+
+// NewQueueConsumer creates a new queue consumer
+func NewQueueConsumer(queueServices *QueueServices, workers int, flushInterval time.Duration, batchSize int) *QueueConsumer {
+	intake, stopIntake := context.WithCancel(context.Background())
+	processing, abortProcessing := context.WithCancel(context.Background())
+	return &QueueConsumer{
+		queueServices:   queueServices,
+		workers:         workers,
+		flushInterval:   flushInterval,
+		batchSize:       batchSize,
+		intake:          intake,
+		stopIntake:      stopIntake,
+		processing:      processing,
+		abortProcessing: abortProcessing,
+		done:            make(chan struct{}),
+	}
+}
+
+// Run processes events until ctx is cancelled or Shutdown is called, then drains the events already received
+func (c *QueueConsumer) Run(ctx context.Context) {
+	defer close(c.done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.stopIntake()
+		case <-c.intake.Done():
+		}
+	}()
+
+	partitions := make([]chan Delivery, c.workers)
+	var wg sync.WaitGroup
+	for i := range partitions {
+		partitions[i] = make(chan Delivery, c.batchSize)
+		wg.Add(1)
+		go func(deliveries <-chan Delivery) {
+			defer wg.Done()
+			c.consume(deliveries)
+		}(partitions[i])
+	}
+
+	c.receive(partitions)
+	wg.Wait()
+}
+
+// Shutdown stops intake and waits for the events already received to be processed.
+// Events still pending when ctx is done are spilled: a durable queue redelivers them on the next start,
+// otherwise they are dead-lettered so they can be replayed.
+func (c *QueueConsumer) Shutdown(ctx context.Context) DrainReport {
+	c.stopIntake()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.abortProcessing()
+		<-c.done
+	}
+	return DrainReport{
+		Processed: atomic.LoadInt64(&c.processed),
+		Spilled:   atomic.LoadInt64(&c.spilled),
+	}
+}
+
+// receive dispatches deliveries from the queue to the partition of their video until intake stops,
+// then hands over the events the queue still buffers and closes the partitions
+func (c *QueueConsumer) receive(partitions []chan Delivery) {
+	defer func() {
+		for _, partition := range partitions {
+			close(partition)
+		}
+	}()
+	queue := c.queueServices.queue
+	for c.intake.Err() == nil {
+		deliveries, err := queue.Dequeue(c.intake, c.batchSize)
+		if err != nil && c.intake.Err() == nil {
+			log.Printf("Error dequeuing interaction events: %v", err)
+			time.Sleep(1 * time.Second) // Back off while the queue is unavailable
+			continue
+		}
+		if !c.dispatch(partitions, deliveries) {
+			return
+		}
+	}
+
+	for {
+		deliveries, err := queue.Drain(c.processing, c.batchSize)
+		if err != nil {
+			log.Printf("Error draining interaction events: %v", err)
+			return
+		}
+		if len(deliveries) == 0 || !c.dispatch(partitions, deliveries) {
+			return
+		}
+	}
+}
+
+// dispatch hands deliveries to their partitions. It spills the rest and returns false once processing is aborted.
+func (c *QueueConsumer) dispatch(partitions []chan Delivery, deliveries []Delivery) bool {
+	for i, delivery := range deliveries {
+		select {
+		case partitions[partitionOf(delivery.Event.VideoID, len(partitions))] <- delivery:
+		case <-c.processing.Done():
+			c.spill(deliveries[i:])
+			return false
+		}
+	}
+	return true
+}
+
+// consume coalesces the deliveries of a partition into batches until the partition is closed
+func (c *QueueConsumer) consume(deliveries <-chan Delivery) {
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Delivery, 0, c.batchSize)
 	for {
 		select {
-		case event := <-queue:
-			QueueServices.DequeueInteractionEvent(event)
-		default:
-			time.Sleep(1 * time.Second) // Sleep to prevent busy waiting
+		case delivery, ok := <-deliveries:
+			if !ok {
+				c.flush(batch)
+				return
+			}
+			batch = append(batch, delivery)
+			if len(batch) < c.batchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-c.processing.Done():
+			for delivery := range deliveries {
+				batch = append(batch, delivery)
+			}
+			c.spill(batch)
+			return
 		}
+		c.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush processes a batch and counts it when it is part of the drain
+func (c *QueueConsumer) flush(batch []Delivery) {
+	processed := c.queueServices.flush(c.processing, batch)
+	if c.intake.Err() != nil {
+		atomic.AddInt64(&c.processed, int64(processed))
+	}
+}
+
+// spill gives up on deliveries at shutdown. A durable queue keeps them unacknowledged for redelivery,
+// otherwise they are dead-lettered.
+func (c *QueueConsumer) spill(deliveries []Delivery) {
+	if len(deliveries) == 0 {
+		return
+	}
+	atomic.AddInt64(&c.spilled, int64(len(deliveries)))
+	if c.queueServices.queue.Durable() {
+		return
+	}
+	events := make([]models.InteractionEvent, len(deliveries))
+	for i, delivery := range deliveries {
+		events[i] = delivery.Event
+	}
+	if err := c.queueServices.deadLetterService.DeadLetter(events, errSpilled, 0); err != nil {
+		log.Printf("Error persisting %d spilled interaction events: %v", len(events), err)
+	}
+}
+
+// partitionOf returns the worker partition of a video
+func partitionOf(videoID uuid.UUID, partitions int) int {
+	hash := fnv.New32a()
+	hash.Write(videoID[:])
+	return int(hash.Sum32() % uint32(partitions))
+}
+
+// flush processes a batch of deliveries and acknowledges them.
+// The deliveries of videos whose events could neither be applied nor dead-lettered are handed back to the queue.
+// Retries give up once ctx is cancelled. It returns how many deliveries were acknowledged.
+func (h *QueueServices) flush(ctx context.Context, batch []Delivery) int {
+	if len(batch) == 0 {
+		return 0
+	}
+	events := make([]models.InteractionEvent, len(batch))
+	for i, delivery := range batch {
+		events[i] = delivery.Event
+	}
+	redeliver := h.ProcessEvents(ctx, events)
+	processed := batch[:0:0]
+	var failed []Delivery
+	for _, delivery := range batch {
+		if redeliver[delivery.Event.VideoID] {
+			failed = append(failed, delivery)
+			continue
+		}
+		processed = append(processed, delivery)
+	}
+	// Neither call is cancelled so applied events are never delivered again and failed ones are not left behind
+	if err := h.queue.Nack(context.Background(), failed...); err != nil {
+		log.Printf("Error handing back %d interaction events for redelivery: %v", len(failed), err)
+	}
+	if err := h.queue.Ack(context.Background(), processed...); err != nil {
+		log.Printf("Error acknowledging interaction events: %v", err)
+		return 0
 	}
-}
\ No newline at end of file
+	return len(processed)
+}
@@ -1,42 +1,131 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"log"
 
+	"github.com/google/uuid"
 	"github.com/trieuvy/video-ranking/internal/models"
+	"gorm.io/gorm"
 )
 
-// processEvent processes a single event from the queue
+// EventRecorder is fed every batch of interaction events once their counters have been updated
+type EventRecorder interface {
+	RecordEvents(ctx context.Context, events []models.InteractionEvent) error
+}
+
+// QueueServices applies the interaction events of the queue to the counters, the leaderboards and the recorders
 type QueueServices struct {
-	videoService *VideoService
-	queue chan models.InteractionEvent
+	videoService      *VideoService
+	deadLetterService *DeadLetterService
+	retryPolicy       RetryPolicy
+	recorders         []EventRecorder
+	queue             EventQueue
 }
 
-func NewQueueServices(videoService *VideoService, queue chan models.InteractionEvent) *QueueServices {
-	return &QueueServices{videoService: videoService,queue: queue}
+func NewQueueServices(videoService *VideoService, queue EventQueue, retryPolicy RetryPolicy, deadLetterService *DeadLetterService, recorders ...EventRecorder) *QueueServices {
+	return &QueueServices{
+		videoService:      videoService,
+		deadLetterService: deadLetterService,
+		retryPolicy:       retryPolicy,
+		recorders:         recorders,
+		queue:             queue,
+	}
 }
 
-func (h *QueueServices) DequeueInteractionEvent(event models.InteractionEvent) {
-	log.Printf("Processing event: %+v", event)
-	if event.Type == models.Like {
-		err := h.videoService.ChangeLikesAmount(event.VideoID, event.Step)
-		if err != nil {
-			log.Printf("Error changing likes amount: %v", err)
+// ProcessEvents coalesces a batch of events per video, applies each video's summed deltas in one update,
+// rescores every touched video once and broadcasts the leaderboards once for the whole batch.
+// Events applied by an earlier delivery are skipped. Failed updates are retried with the retry policy.
+// Events whose counters still cannot be updated are dead-lettered and not passed on to the recorders.
+// It returns the videos whose events could not even be dead-lettered and must be delivered again.
+func (h *QueueServices) ProcessEvents(ctx context.Context, events []models.InteractionEvent) map[uuid.UUID]bool {
+	if len(events) == 0 {
+		return nil
+	}
+	log.Printf("Processing %d events", len(events))
+
+	byVideo := make(map[uuid.UUID][]models.InteractionEvent)
+	var order []uuid.UUID
+	for _, event := range events {
+		if _, ok := byVideo[event.VideoID]; !ok {
+			order = append(order, event.VideoID)
 		}
-	} else if event.Type == models.View {
-		err := h.videoService.ChangeViewsAmount(event.VideoID, event.Step)
-		if err != nil {
-			log.Printf("Error changing views amount: %v", err)
+		byVideo[event.VideoID] = append(byVideo[event.VideoID], event)
+	}
+
+	redeliver := make(map[uuid.UUID]bool)
+	notified := make(map[string]bool)
+	var notify []models.Tag
+	var rescored []models.Video
+	recorded := make([]models.InteractionEvent, 0, len(events))
+	for _, videoID := range order {
+		video, fresh, applied, attempts, err := h.applyEvents(ctx, videoID, byVideo[videoID])
+		if err != nil && applied {
+			// The counters are correct, only the leaderboards are stale until the video's next event
+			log.Printf("Error updating ranking of video %s after %d attempts: %v", videoID, attempts, err)
+		} else if err != nil {
+			log.Printf("Error changing counters of video %s after %d attempts: %v", videoID, attempts, err)
+			if err := h.deadLetterService.DeadLetter(byVideo[videoID], err, attempts); err != nil {
+				log.Printf("Error dead-lettering events of video %s: %v", videoID, err)
+				redeliver[videoID] = true
+			}
+			continue
+		}
+		recorded = append(recorded, fresh...)
+		if video == nil {
+			continue
+		}
+		rescored = append(rescored, *video)
+		notify = appendNewTags(notify, notified, video)
+	}
+	if len(rescored) > 0 {
+		if err := h.videoService.NotifyRankings(ctx, notify); err != nil {
+			log.Printf("Error broadcasting trending videos: %v", err)
 		}
-	} else if event.Type == models.Comment {
-		err := h.videoService.ChangeCommentsAmount(event.VideoID, event.Step)
-		if err != nil {
-			log.Printf("Error changing comments amount: %v", err)
+		if err := h.videoService.NotifyVideos(ctx, rescored); err != nil {
+			log.Printf("Error broadcasting video counters: %v", err)
 		}
 	}
+
+	if len(recorded) == 0 {
+		return redeliver
+	}
+	for _, recorder := range h.recorders {
+		if err := recorder.RecordEvents(ctx, recorded); err != nil {
+			log.Printf("Error recording events with %T: %v", recorder, err)
+		}
+	}
+	return redeliver
+}
+
+// applyEvents changes the counters of a video and rescores it, retrying each step until it succeeds.
+// It returns the rescored video, nil when no event was new or the video was deleted, and the events that were new.
+// applied reports whether the counters were changed, in which case the change must not be repeated.
+func (h *QueueServices) applyEvents(ctx context.Context, videoID uuid.UUID, events []models.InteractionEvent) (video *models.Video, fresh []models.InteractionEvent, applied bool, attempts int, err error) {
+	attempts, err = h.retryPolicy.Do(ctx, func() error {
+		if !applied {
+			var err error
+			if fresh, err = h.videoService.ApplyEvents(videoID, events); err != nil {
+				return err
+			}
+			applied = true
+		}
+		if len(fresh) == 0 {
+			// Every event was applied by an earlier delivery
+			return nil
+		}
+		var err error
+		video, err = h.videoService.UpdateRanking(ctx, videoID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The video was deleted in the meantime so there is nothing left to rank
+			return nil
+		}
+		return err
+	})
+	return video, fresh, applied, attempts, err
 }
 
-func (s *QueueServices) EnqueueInteractionEvent(event models.InteractionEvent) error {
-	s.queue <- event
-	return nil
+func (s *QueueServices) EnqueueInteractionEvent(ctx context.Context, event models.InteractionEvent) error {
+	return s.queue.Enqueue(ctx, event)
 }
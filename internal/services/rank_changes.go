@@ -0,0 +1,66 @@
+package services
+
+import "sort"
+
+// Rank movements reported in trending notifications
+const (
+	MovementUp      = "up"
+	MovementDown    = "down"
+	MovementSame    = "same"
+	MovementNew     = "new"
+	MovementDropped = "dropped"
+)
+
+// rankedEntry is the position of a video in a published leaderboard
+type rankedEntry struct {
+	rank  int
+	score float64
+}
+
+// annotateRankChanges adds previous_rank, movement and score_delta to every entry of
+// trendingVideos by comparing it with the previously published leaderboard.
+// It returns the entries that left the leaderboard, ordered by their previous rank, and the snapshot to remember for the next call.
+func annotateRankChanges(previous map[string]rankedEntry, trendingVideos []map[string]interface{}) ([]map[string]interface{}, map[string]rankedEntry) {
+	current := make(map[string]rankedEntry, len(trendingVideos))
+	for _, videoData := range trendingVideos {
+		videoID := videoData["video_id"].(string)
+		rank := videoData["rank"].(int)
+		score := videoData["score"].(float64)
+		current[videoID] = rankedEntry{rank: rank, score: score}
+
+		prev, ok := previous[videoID]
+		if !ok {
+			videoData["previous_rank"] = nil
+			videoData["movement"] = MovementNew
+			videoData["score_delta"] = score
+			continue
+		}
+		videoData["previous_rank"] = prev.rank
+		videoData["score_delta"] = score - prev.score
+		switch {
+		case rank < prev.rank:
+			videoData["movement"] = MovementUp
+		case rank > prev.rank:
+			videoData["movement"] = MovementDown
+		default:
+			videoData["movement"] = MovementSame
+		}
+	}
+
+	dropped := []map[string]interface{}{}
+	for videoID, prev := range previous {
+		if _, ok := current[videoID]; ok {
+			continue
+		}
+		dropped = append(dropped, map[string]interface{}{
+			"video_id":      videoID,
+			"previous_rank": prev.rank,
+			"score":         prev.score,
+			"movement":      MovementDropped,
+		})
+	}
+	sort.Slice(dropped, func(i, j int) bool {
+		return dropped[i]["previous_rank"].(int) < dropped[j]["previous_rank"].(int)
+	})
+	return dropped, current
+}
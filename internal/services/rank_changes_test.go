@@ -0,0 +1,105 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnnotateRankChanges(t *testing.T) {
+	tests := []struct {
+		name         string
+		previous     map[string]rankedEntry
+		current      []map[string]interface{}
+		wantMovement map[string]string
+		wantPrevious map[string]interface{}
+		wantDelta    map[string]float64
+		wantDropped  []string
+	}{
+		{
+			name:     "first broadcast marks every video new",
+			previous: nil,
+			current: []map[string]interface{}{
+				{"video_id": "a", "rank": 1, "score": 10.0},
+				{"video_id": "b", "rank": 2, "score": 5.0},
+			},
+			wantMovement: map[string]string{"a": MovementNew, "b": MovementNew},
+			wantPrevious: map[string]interface{}{"a": nil, "b": nil},
+			wantDelta:    map[string]float64{"a": 10, "b": 5},
+			wantDropped:  []string{},
+		},
+		{
+			name: "videos moving up, down and staying",
+			previous: map[string]rankedEntry{
+				"a": {rank: 1, score: 10},
+				"b": {rank: 2, score: 8},
+				"c": {rank: 3, score: 6},
+			},
+			current: []map[string]interface{}{
+				{"video_id": "b", "rank": 1, "score": 12.0},
+				{"video_id": "a", "rank": 2, "score": 10.0},
+				{"video_id": "c", "rank": 3, "score": 7.0},
+			},
+			wantMovement: map[string]string{"a": MovementDown, "b": MovementUp, "c": MovementSame},
+			wantPrevious: map[string]interface{}{"a": 1, "b": 2, "c": 3},
+			wantDelta:    map[string]float64{"a": 0, "b": 4, "c": 1},
+			wantDropped:  []string{},
+		},
+		{
+			name: "dropped videos are ordered by previous rank",
+			previous: map[string]rankedEntry{
+				"a": {rank: 1, score: 10},
+				"b": {rank: 2, score: 8},
+				"c": {rank: 3, score: 6},
+				"d": {rank: 4, score: 4},
+				"e": {rank: 5, score: 2},
+			},
+			current: []map[string]interface{}{
+				{"video_id": "c", "rank": 1, "score": 20.0},
+				{"video_id": "f", "rank": 2, "score": 15.0},
+			},
+			wantMovement: map[string]string{"c": MovementUp, "f": MovementNew},
+			wantPrevious: map[string]interface{}{"c": 3, "f": nil},
+			wantDelta:    map[string]float64{"c": 14, "f": 15},
+			wantDropped:  []string{"a", "b", "d", "e"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dropped, snapshot := annotateRankChanges(tt.previous, tt.current)
+
+			for _, videoData := range tt.current {
+				videoID := videoData["video_id"].(string)
+				if videoData["movement"] != tt.wantMovement[videoID] {
+					t.Errorf("%s movement = %v, want %v", videoID, videoData["movement"], tt.wantMovement[videoID])
+				}
+				if !reflect.DeepEqual(videoData["previous_rank"], tt.wantPrevious[videoID]) {
+					t.Errorf("%s previous_rank = %v, want %v", videoID, videoData["previous_rank"], tt.wantPrevious[videoID])
+				}
+				if videoData["score_delta"] != tt.wantDelta[videoID] {
+					t.Errorf("%s score_delta = %v, want %v", videoID, videoData["score_delta"], tt.wantDelta[videoID])
+				}
+				want := rankedEntry{rank: videoData["rank"].(int), score: videoData["score"].(float64)}
+				if snapshot[videoID] != want {
+					t.Errorf("snapshot of %s = %+v, want %+v", videoID, snapshot[videoID], want)
+				}
+			}
+			if len(snapshot) != len(tt.current) {
+				t.Errorf("snapshot holds %d videos, want %d", len(snapshot), len(tt.current))
+			}
+
+			droppedIDs := make([]string, len(dropped))
+			for i, videoData := range dropped {
+				droppedIDs[i] = videoData["video_id"].(string)
+				if videoData["movement"] != MovementDropped {
+					t.Errorf("dropped %s movement = %v, want %v", droppedIDs[i], videoData["movement"], MovementDropped)
+				}
+				if videoData["previous_rank"] != tt.previous[droppedIDs[i]].rank {
+					t.Errorf("dropped %s previous_rank = %v, want %v", droppedIDs[i], videoData["previous_rank"], tt.previous[droppedIDs[i]].rank)
+				}
+			}
+			if !reflect.DeepEqual(droppedIDs, tt.wantDropped) {
+				t.Errorf("dropped = %v, want %v", droppedIDs, tt.wantDropped)
+			}
+		})
+	}
+}
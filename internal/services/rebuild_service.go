@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+const (
+	// rebuildLockKey makes sure a single instance rebuilds the leaderboard at a time
+	rebuildLockKey = "video:scores:rebuild:lock"
+	// rebuildKeyPrefix prefixes the sorted sets a rebuild is written to before being swapped in
+	rebuildKeyPrefix = "video:scores:rebuilding:"
+	// rebuildBatchSize is how many videos are read and written per round-trip while rebuilding
+	rebuildBatchSize = 1000
+	// rebuildKeyTTL removes the sorted set of a rebuild that failed before being swapped in
+	rebuildKeyTTL = time.Hour
+)
+
+// RebuildResult describes the outcome of a leaderboard rebuild
+type RebuildResult struct {
+	// Rebuilt is false when the leaderboards were complete and the rebuild was not forced
+	Rebuilt bool `json:"rebuilt"`
+	// Members is how many videos the leaderboard held before the rebuild
+	Members int64 `json:"members"`
+	// Scored is how many videos have a stored score
+	Scored int64 `json:"scored"`
+	// Videos is how many videos were written to the rebuilt leaderboard
+	Videos int `json:"videos"`
+	// CreatorMembers is how many creators the creator leaderboard held before the rebuild
+	CreatorMembers int64 `json:"creator_members"`
+	// Creators is how many creators were written to the rebuilt creator leaderboard
+	Creators int `json:"creators"`
+}
+
+// RebuildService restores the all-time leaderboard from the scores stored with the videos,
+// for when Redis lost its data and the leaderboard would otherwise stay empty until every video gets a new event
+type RebuildService struct {
+	videoRepo   *repositories.VideoRepository
+	redisClient *redis.Client
+	lockTTL     time.Duration
+}
+
+// NewRebuildService creates a new rebuild service. lockTTL bounds how long a crashed rebuild keeps other instances from rebuilding.
+func NewRebuildService(videoRepo *repositories.VideoRepository, redisClient *redis.Client, lockTTL time.Duration) *RebuildService {
+	return &RebuildService{
+		videoRepo:   videoRepo,
+		redisClient: redisClient,
+		lockTTL:     lockTTL,
+	}
+}
+
+// Rebuild repopulates the all-time leaderboard from videos.score and the creator leaderboard from the sums of
+// those scores when either is missing or holds fewer members than the database has, or always when force is set.
+// Each leaderboard is written to a separate key and swapped in at once so readers never see a partial one.
+// Scores changed while the rebuild runs are reflected on the next event of their video.
+// It returns ErrLockHeld when another instance is rebuilding.
+func (s *RebuildService) Rebuild(ctx context.Context, force bool) (*RebuildResult, error) {
+	release, err := acquireLock(ctx, s.redisClient, rebuildLockKey, s.lockTTL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	members, err := s.redisClient.ZCard(ctx, videoScoresKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	creatorMembers, err := s.redisClient.ZCard(ctx, creatorScoresKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	scored, err := s.videoRepo.CountScored()
+	if err != nil {
+		return nil, err
+	}
+	creators, err := s.videoRepo.SumScoresByCreator()
+	if err != nil {
+		return nil, err
+	}
+	result := &RebuildResult{Members: members, Scored: scored, CreatorMembers: creatorMembers}
+	if !force && members >= scored && creatorMembers >= int64(len(creators)) {
+		return result, nil
+	}
+
+	if result.Videos, err = s.restore(ctx, creators); err != nil {
+		return nil, err
+	}
+	result.Creators = len(creators)
+	result.Rebuilt = true
+	return result, nil
+}
+
+// restore replaces the all-time leaderboard with the stored video scores and the creator leaderboard with creators,
+// and returns how many videos were written. The caller must hold rebuildLockKey.
+func (s *RebuildService) restore(ctx context.Context, creators []repositories.CreatorScore) (int, error) {
+	written, err := s.rebuildVideos(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return written, s.rebuildCreators(ctx, creators)
+}
+
+// rebuildVideos replaces the all-time leaderboard with the stored video scores and returns how many videos it holds
+func (s *RebuildService) rebuildVideos(ctx context.Context) (int, error) {
+	key := rebuildKeyPrefix + uuid.New().String()
+	written := 0
+	for afterID := uuid.Nil; ; {
+		videos, err := s.videoRepo.ListScoredAfter(afterID, rebuildBatchSize)
+		if err != nil {
+			s.redisClient.Del(context.Background(), key)
+			return 0, err
+		}
+		if len(videos) == 0 {
+			break
+		}
+		afterID = videos[len(videos)-1].ID
+
+		batch := make([]*redis.Z, len(videos))
+		for i, video := range videos {
+			batch[i] = &redis.Z{Score: video.Score, Member: video.ID.String()}
+		}
+		if err := s.writeBatch(ctx, key, batch); err != nil {
+			return 0, err
+		}
+		written += len(videos)
+	}
+	return written, swapSortedSet(ctx, s.redisClient, key, videoScoresKey, written)
+}
+
+// rebuildCreators replaces the creator leaderboard with the sums of the stored video scores
+func (s *RebuildService) rebuildCreators(ctx context.Context, creators []repositories.CreatorScore) error {
+	key := rebuildKeyPrefix + uuid.New().String()
+	for start := 0; start < len(creators); start += rebuildBatchSize {
+		end := start + rebuildBatchSize
+		if end > len(creators) {
+			end = len(creators)
+		}
+		batch := make([]*redis.Z, 0, end-start)
+		for _, creator := range creators[start:end] {
+			batch = append(batch, &redis.Z{Score: creator.Score, Member: creator.CreatedBy.String()})
+		}
+		if err := s.writeBatch(ctx, key, batch); err != nil {
+			return err
+		}
+	}
+	return swapSortedSet(ctx, s.redisClient, key, creatorScoresKey, len(creators))
+}
+
+// writeBatch adds members to the temporary sorted set of a rebuild, removing it when writing fails
+func (s *RebuildService) writeBatch(ctx context.Context, key string, batch []*redis.Z) error {
+	pipe := s.redisClient.Pipeline()
+	pipe.ZAdd(ctx, key, batch...)
+	pipe.Expire(ctx, key, rebuildKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.redisClient.Del(context.Background(), key)
+		return err
+	}
+	return nil
+}
+
+// swapSortedSet replaces the sorted set at key with the temporary one holding written members.
+// RENAME replaces it in one step so readers never see a partial one; an empty result deletes key instead.
+func swapSortedSet(ctx context.Context, redisClient *redis.Client, tmpKey, key string, written int) error {
+	if written == 0 {
+		return redisClient.Del(ctx, key).Err()
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.Persist(ctx, tmpKey)
+	pipe.Rename(ctx, tmpKey, key)
+	_, err := pipe.Exec(ctx)
+	return err
+}
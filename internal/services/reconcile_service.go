@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	// reconcileLockKey makes sure a single instance reconciles at a time
+	reconcileLockKey = "reconcile:lock"
+	// reconcileReportKey holds the report of the last reconciliation
+	reconcileReportKey = "reconcile:report"
+	// reconcileBatchSize is how many videos are recounted per query
+	reconcileBatchSize = 500
+	// reconcileReportLimit caps how many drifted videos and orphans are listed in a report
+	reconcileReportLimit = 100
+	// scoreTolerance absorbs the rounding of scores stored as floats
+	scoreTolerance = 1e-6
+)
+
+// ErrNoReconcileReport is returned when no reconciliation has completed yet
+var ErrNoReconcileReport = errors.New("no reconciliation has run yet")
+
+// VideoCounters are the like, view and comment counts of a video
+type VideoCounters struct {
+	Likes    int64 `json:"likes"`
+	Views    int64 `json:"views"`
+	Comments int64 `json:"comments"`
+}
+
+// CounterDrift is a video whose stored counters differ from its interactions
+type CounterDrift struct {
+	VideoID uuid.UUID     `json:"video_id"`
+	Stored  VideoCounters `json:"stored"`
+	Counted VideoCounters `json:"counted"`
+}
+
+// DriftReport describes what a reconciliation found and repaired
+type DriftReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DryRun     bool      `json:"dry_run"`
+	// VideosChecked counts the videos recounted
+	VideosChecked int `json:"videos_checked"`
+	// Skipped counts the videos left alone because events of theirs are still in flight or dead-lettered
+	Skipped int `json:"skipped"`
+	// CounterDrifts counts the videos whose counters differ from their interactions
+	CounterDrifts int `json:"counter_drifts"`
+	// ScoreDrifts counts the videos whose stored or leaderboard score differs from the score of their counters
+	ScoreDrifts int `json:"score_drifts"`
+	// MissingMembers counts the videos with interactions that are missing from the leaderboard
+	MissingMembers int `json:"missing_members"`
+	// OrphanMembers counts the leaderboard members whose video no longer exists
+	OrphanMembers int `json:"orphan_members"`
+	// CreatorDrifts counts the creators whose leaderboard score differs from the sum of the scores of their videos
+	CreatorDrifts int `json:"creator_drifts"`
+	// Repaired counts the videos and orphans fixed, always 0 on a dry run
+	Repaired int            `json:"repaired"`
+	Drifts   []CounterDrift `json:"drifts"`
+	Orphans  []string       `json:"orphans"`
+}
+
+// ReconcileService recounts the counters of every video from the interactions table and repairs
+// the counter columns, the scores and the leaderboards that drifted from it
+type ReconcileService struct {
+	videoRepo       *repositories.VideoRepository
+	interactionRepo *repositories.InteractionRepository
+	logRepo         *repositories.InteractionLogRepository
+	deadLetterRepo  *repositories.DeadLetterRepository
+	outboxRepo      *repositories.OutboxRepository
+	videoService    *VideoService
+	redisClient     *redis.Client
+	settle          time.Duration
+	lockTTL         time.Duration
+}
+
+// NewReconcileService creates a new reconcile service.
+// Videos with events that occurred within settle or that the outbox shows were not processed yet are skipped
+// since their counters may still catch up.
+// lockTTL bounds how long a crashed run keeps other instances from reconciling.
+func NewReconcileService(videoRepo *repositories.VideoRepository, interactionRepo *repositories.InteractionRepository, logRepo *repositories.InteractionLogRepository, deadLetterRepo *repositories.DeadLetterRepository, outboxRepo *repositories.OutboxRepository, videoService *VideoService, redisClient *redis.Client, settle, lockTTL time.Duration) *ReconcileService {
+	return &ReconcileService{
+		videoRepo:       videoRepo,
+		interactionRepo: interactionRepo,
+		logRepo:         logRepo,
+		deadLetterRepo:  deadLetterRepo,
+		outboxRepo:      outboxRepo,
+		videoService:    videoService,
+		redisClient:     redisClient,
+		settle:          settle,
+		lockTTL:         lockTTL,
+	}
+}
+
+// Run reconciles at every interval until ctx is cancelled
+func (s *ReconcileService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Reconcile(ctx, false)
+			if errors.Is(err, ErrLockHeld) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error reconciling video counters: %v", err)
+				continue
+			}
+			if report.CounterDrifts+report.ScoreDrifts+report.MissingMembers+report.OrphanMembers+report.CreatorDrifts > 0 {
+				log.Printf("Reconciliation repaired %d counter drifts, %d score drifts, %d missing and %d orphan leaderboard members and %d creator drifts",
+					report.CounterDrifts, report.ScoreDrifts, report.MissingMembers, report.OrphanMembers, report.CreatorDrifts)
+			}
+		}
+	}
+}
+
+// Reconcile recounts every video from the interactions table, repairs the drifted ones unless dryRun is set
+// and stores the report so it can be retrieved with LastReport.
+// It returns ErrLockHeld when another instance is reconciling.
+func (s *ReconcileService) Reconcile(ctx context.Context, dryRun bool) (*DriftReport, error) {
+	release, err := acquireLock(ctx, s.redisClient, reconcileLockKey, s.lockTTL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	report := &DriftReport{
+		StartedAt: time.Now(),
+		DryRun:    dryRun,
+		Drifts:    []CounterDrift{},
+		Orphans:   []string{},
+	}
+	notified := make(map[string]bool)
+	var notify []models.Tag
+	var repaired []models.Video
+	for afterID := uuid.Nil; ; {
+		videos, err := s.videoRepo.ListAfter(afterID, reconcileBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(videos) == 0 {
+			break
+		}
+		afterID = videos[len(videos)-1].ID
+		batchRepaired, err := s.reconcileBatch(ctx, videos, report)
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range batchRepaired {
+			notify = appendNewTags(notify, notified, &video)
+		}
+		repaired = append(repaired, batchRepaired...)
+	}
+	if err := s.removeOrphans(ctx, report); err != nil {
+		return nil, err
+	}
+	if err := s.reconcileCreators(ctx, report); err != nil {
+		return nil, err
+	}
+	report.FinishedAt = time.Now()
+
+	if report.Repaired > 0 {
+		if err := s.videoService.NotifyRankings(ctx, notify); err != nil {
+			log.Printf("Error broadcasting trending videos after reconciliation: %v", err)
+		}
+		if err := s.videoService.NotifyVideos(ctx, repaired); err != nil {
+			log.Printf("Error broadcasting video counters after reconciliation: %v", err)
+		}
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redisClient.Set(ctx, reconcileReportKey, data, 0).Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// LastReport retrieves the report of the last reconciliation
+func (s *ReconcileService) LastReport(ctx context.Context) (*DriftReport, error) {
+	data, err := s.redisClient.Get(ctx, reconcileReportKey).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNoReconcileReport
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report DriftReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// reconcileBatch compares a batch of videos with their interactions and leaderboard scores and repairs them.
+// It returns the repaired videos.
+func (s *ReconcileService) reconcileBatch(ctx context.Context, videos []models.Video, report *DriftReport) ([]models.Video, error) {
+	ids := make([]uuid.UUID, len(videos))
+	for i, video := range videos {
+		ids[i] = video.ID
+	}
+	skip, err := s.unsettled(ids)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.interactionRepo.CountByVideos(ids)
+	if err != nil {
+		return nil, err
+	}
+	counted := make(map[uuid.UUID]VideoCounters, len(totals))
+	for _, total := range totals {
+		counted[total.VideoID] = VideoCounters{Likes: total.Likes, Views: total.Views, Comments: total.Comments}
+	}
+
+	pipe := s.redisClient.Pipeline()
+	ranked := make([]*redis.FloatCmd, len(videos))
+	for i, video := range videos {
+		ranked[i] = pipe.ZScore(ctx, videoScoresKey, video.ID.String())
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	scorer := s.videoService.Scorer()
+	var repaired []models.Video
+	for i, video := range videos {
+		if skip[video.ID] {
+			report.Skipped++
+			continue
+		}
+		report.VideosChecked++
+
+		stored := VideoCounters{Likes: video.Likes, Views: video.Views, Comments: video.Comments}
+		actual := counted[video.ID]
+		counterDrift := stored != actual
+		if counterDrift {
+			report.CounterDrifts++
+			if len(report.Drifts) < reconcileReportLimit {
+				report.Drifts = append(report.Drifts, CounterDrift{VideoID: video.ID, Stored: stored, Counted: actual})
+			}
+		}
+
+		expected := scorer.Score(actual.Views, actual.Likes, actual.Comments)
+		rankedScore, err := ranked[i].Result()
+		missing := err == redis.Nil && actual != VideoCounters{}
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		scoreDrift := !missing && !counterDrift &&
+			(math.Abs(video.Score-expected) > scoreTolerance || (err == nil && math.Abs(rankedScore-expected) > scoreTolerance))
+		if missing {
+			report.MissingMembers++
+		}
+		if scoreDrift {
+			report.ScoreDrifts++
+		}
+		if report.DryRun || !(counterDrift || missing || scoreDrift) {
+			continue
+		}
+
+		rescored, err := s.repair(ctx, video.ID, counterDrift, actual)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The video was deleted in the meantime; its leaderboard member is removed as an orphan
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Repaired++
+		repaired = append(repaired, *rescored)
+	}
+	return repaired, nil
+}
+
+// repair overwrites the counters of a video when they drifted and rescores it in every leaderboard
+func (s *ReconcileService) repair(ctx context.Context, videoID uuid.UUID, counterDrift bool, counters VideoCounters) (*models.Video, error) {
+	if counterDrift {
+		if err := s.videoRepo.SetCounters(videoID, counters.Likes, counters.Views, counters.Comments); err != nil {
+			return nil, err
+		}
+	}
+	return s.videoService.UpdateRanking(ctx, videoID)
+}
+
+// unsettled returns the videos whose counters may legitimately lag their interactions: those with recent events,
+// those with events not processed yet however old, since the relay or the queue may be backed up,
+// and those with dead-lettered events awaiting replay
+func (s *ReconcileService) unsettled(ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	active, err := s.logRepo.FindVideosActiveSince(ids, time.Now().Add(-s.settle))
+	if err != nil {
+		return nil, err
+	}
+	deadLettered, err := s.deadLetterRepo.FindVideoIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	unprocessed, err := s.outboxRepo.FindUnprocessedVideoIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[uuid.UUID]bool, len(active)+len(unprocessed)+len(deadLettered))
+	for _, id := range append(append(active, unprocessed...), deadLettered...) {
+		skip[id] = true
+	}
+	return skip, nil
+}
+
+// removeOrphans removes the members of the all-time leaderboard whose video no longer exists
+// from every sorted set a video can belong to
+func (s *ReconcileService) removeOrphans(ctx context.Context, report *DriftReport) error {
+	keys, err := videoMemberKeys(ctx, s.redisClient)
+	if err != nil {
+		return err
+	}
+	var cursor uint64
+	for {
+		members, next, err := s.redisClient.ZScan(ctx, videoScoresKey, cursor, "", reconcileBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		// ZSCAN returns members and scores interleaved
+		ids := make([]uuid.UUID, 0, len(members)/2)
+		var orphans []string
+		for i := 0; i < len(members); i += 2 {
+			id, err := uuid.Parse(members[i])
+			if err != nil {
+				orphans = append(orphans, members[i])
+				continue
+			}
+			ids = append(ids, id)
+		}
+		videos, err := s.videoRepo.FindByIDs(ids)
+		if err != nil {
+			return err
+		}
+		exists := make(map[uuid.UUID]bool, len(videos))
+		for _, video := range videos {
+			exists[video.ID] = true
+		}
+		for _, id := range ids {
+			if !exists[id] {
+				orphans = append(orphans, id.String())
+			}
+		}
+
+		if len(orphans) > 0 {
+			report.OrphanMembers += len(orphans)
+			for _, orphan := range orphans {
+				if len(report.Orphans) < reconcileReportLimit {
+					report.Orphans = append(report.Orphans, orphan)
+				}
+			}
+			if !report.DryRun {
+				members := make([]interface{}, len(orphans))
+				for i, orphan := range orphans {
+					members[i] = orphan
+				}
+				pipe := s.redisClient.TxPipeline()
+				for _, key := range keys {
+					pipe.ZRem(ctx, key, members...)
+				}
+				if _, err := pipe.Exec(ctx); err != nil {
+					return err
+				}
+				report.Repaired += len(orphans)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// reconcileCreators sets every creator score to the sum of the scores of their videos
+// and removes the creators left without a scored video
+func (s *ReconcileService) reconcileCreators(ctx context.Context, report *DriftReport) error {
+	creators, err := s.videoRepo.SumScoresByCreator()
+	if err != nil {
+		return err
+	}
+	ranked, err := s.redisClient.ZRangeWithScores(ctx, creatorScoresKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]float64, len(ranked))
+	for _, z := range ranked {
+		current[z.Member.(string)] = z.Score
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	drifts := 0
+	for _, creator := range creators {
+		member := creator.CreatedBy.String()
+		score, ok := current[member]
+		delete(current, member)
+		if ok && math.Abs(score-creator.Score) <= scoreTolerance {
+			continue
+		}
+		drifts++
+		pipe.ZAdd(ctx, creatorScoresKey, &redis.Z{Score: creator.Score, Member: member})
+	}
+	for member := range current {
+		drifts++
+		pipe.ZRem(ctx, creatorScoresKey, member)
+	}
+	report.CreatorDrifts = drifts
+	if report.DryRun || drifts == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	report.Repaired += drifts
+	return nil
+}
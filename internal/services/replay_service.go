@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// ReplayResult describes a leaderboard rebuilt from the interaction log
+type ReplayResult struct {
+	Scorer string    `json:"scorer"`
+	From   time.Time `json:"from"`
+	Videos int       `json:"videos"`
+}
+
+// ReplayService rebuilds the all-time leaderboard from the interaction log
+type ReplayService struct {
+	logRepo        *repositories.InteractionLogRepository
+	videoRepo      *repositories.VideoRepository
+	rebuildService *RebuildService
+	scorer         Scorer
+}
+
+// NewReplayService creates a new replay service. Replays score videos with scorer unless another one is requested.
+func NewReplayService(logRepo *repositories.InteractionLogRepository, videoRepo *repositories.VideoRepository, rebuildService *RebuildService, scorer Scorer) *ReplayService {
+	return &ReplayService{
+		logRepo:        logRepo,
+		videoRepo:      videoRepo,
+		rebuildService: rebuildService,
+		scorer:         scorer,
+	}
+}
+
+// Replay sums the logged events that occurred at or after from, scores every video with events using the named
+// scorer, or the configured one when scorerName is empty, and swaps in the all-time leaderboard built from them.
+// A zero from replays the whole log. The creator leaderboard is rebuilt from the replayed scores so it stays the sum
+// of its videos' entries; the scores and counters stored with the videos are left as they are.
+// It holds the rebuild lock so it never races a rebuild, and returns ErrLockHeld when one is running.
+// Events processed while the replay runs, and any later event of a video, rescore it with the configured scorer.
+func (s *ReplayService) Replay(ctx context.Context, from time.Time, scorerName string) (*ReplayResult, error) {
+	scorer := s.scorer
+	if scorerName != "" {
+		var err error
+		if scorer, err = NewScorer(scorerName); err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := acquireLock(ctx, s.rebuildService.redisClient, rebuildLockKey, s.rebuildService.lockTTL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	totals, err := s.logRepo.SumSince(from)
+	if err != nil {
+		return nil, err
+	}
+
+	key := rebuildKeyPrefix + uuid.New().String()
+	creatorScores := make(map[uuid.UUID]float64)
+	written := 0
+	for start := 0; start < len(totals); start += rebuildBatchSize {
+		end := start + rebuildBatchSize
+		if end > len(totals) {
+			end = len(totals)
+		}
+		ids := make([]uuid.UUID, 0, end-start)
+		for _, total := range totals[start:end] {
+			ids = append(ids, total.VideoID)
+		}
+		// Deleted videos are not found and stay off the leaderboard
+		videos, err := s.videoRepo.FindByIDs(ids)
+		if err != nil {
+			s.rebuildService.redisClient.Del(context.Background(), key)
+			return nil, err
+		}
+		creators := make(map[uuid.UUID]uuid.UUID, len(videos))
+		for _, video := range videos {
+			creators[video.ID] = video.CreatedBy
+		}
+
+		batch := make([]*redis.Z, 0, len(videos))
+		for _, total := range totals[start:end] {
+			creator, ok := creators[total.VideoID]
+			if !ok {
+				continue
+			}
+			score := scorer.Score(total.Views, total.Likes, total.Comments)
+			batch = append(batch, &redis.Z{Score: score, Member: total.VideoID.String()})
+			creatorScores[creator] += score
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		if err := s.rebuildService.writeBatch(ctx, key, batch); err != nil {
+			return nil, err
+		}
+		written += len(batch)
+	}
+	if err := swapSortedSet(ctx, s.rebuildService.redisClient, key, videoScoresKey, written); err != nil {
+		return nil, err
+	}
+
+	creators := make([]repositories.CreatorScore, 0, len(creatorScores))
+	for creator, score := range creatorScores {
+		creators = append(creators, repositories.CreatorScore{CreatedBy: creator, Score: score})
+	}
+	if err := s.rebuildService.rebuildCreators(ctx, creators); err != nil {
+		return nil, err
+	}
+	return &ReplayResult{Scorer: scorer.Name(), From: from, Videos: written}, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy retries failed operations with exponential backoff
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NewRetryPolicy creates a policy making up to maxAttempts tries, waiting baseDelay after the first
+// failure and doubling the wait after every further failure up to maxDelay
+func NewRetryPolicy(maxAttempts int, baseDelay, maxDelay time.Duration) RetryPolicy {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return RetryPolicy{MaxAttempts: maxAttempts, BaseDelay: baseDelay, MaxDelay: maxDelay}
+}
+
+// Do calls fn until it succeeds, the attempts are exhausted or ctx is cancelled.
+// It returns the number of attempts made and the last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) (int, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= p.MaxAttempts {
+			return attempt, err
+		}
+		select {
+		case <-time.After(p.Backoff(attempt)):
+		case <-ctx.Done():
+			return attempt, err
+		}
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"first failure waits the base delay", NewRetryPolicy(5, 100*time.Millisecond, 5*time.Second), 1, 100 * time.Millisecond},
+		{"second failure doubles the delay", NewRetryPolicy(5, 100*time.Millisecond, 5*time.Second), 2, 200 * time.Millisecond},
+		{"fourth failure doubles it three times", NewRetryPolicy(5, 100*time.Millisecond, 5*time.Second), 4, 800 * time.Millisecond},
+		{"delay is capped at the max delay", NewRetryPolicy(10, 100*time.Millisecond, 500*time.Millisecond), 4, 500 * time.Millisecond},
+		{"late attempts stay at the max delay", NewRetryPolicy(100, time.Second, 5*time.Second), 80, 5 * time.Second},
+		{"base delay above the max delay is capped", NewRetryPolicy(5, 10*time.Second, 5*time.Second), 1, 5 * time.Second},
+		{"zero base delay never waits", NewRetryPolicy(5, 0, time.Second), 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+				t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
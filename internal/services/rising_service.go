@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+const (
+	// rateBucketPrefix prefixes the per-minute sorted sets of interaction counts of each type
+	rateBucketPrefix = "video:rate:min:"
+	// rateRecentKey and rateBaselineKey cache the scores of the recent and baseline buckets
+	rateRecentKey   = "video:rate:recent"
+	rateBaselineKey = "video:rate:baseline"
+	rateBucketSize  = time.Minute
+	rateBucketFmt   = "200601021504"
+	// risingCandidates is how many of the most active recent videos are considered for rising
+	risingCandidates = 200
+	// risingPriorRate smooths the baseline so a couple of events on a silent video do not dominate
+	risingPriorRate = 0.1
+)
+
+// RisingScore holds the engagement velocity of a video
+type RisingScore struct {
+	VideoID      string
+	Score        float64
+	RecentRate   float64
+	BaselineRate float64
+}
+
+// RisingService tracks short-term interaction rates to surface fast-growing videos
+type RisingService struct {
+	redisClient    *redis.Client
+	scorer         Scorer
+	overrides      *OverrideService
+	recentWindow   time.Duration
+	baselineWindow time.Duration
+	cacheTTL       time.Duration
+}
+
+// NewRisingService creates a new rising service.
+// The rate at which scorer's score grows over recentWindow is compared with its rate over the baselineWindow preceding it.
+func NewRisingService(redisClient *redis.Client, scorer Scorer, overrides *OverrideService, recentWindow, baselineWindow, cacheTTL time.Duration) *RisingService {
+	return &RisingService{
+		redisClient:    redisClient,
+		scorer:         scorer,
+		overrides:      overrides,
+		recentWindow:   recentWindow,
+		baselineWindow: baselineWindow,
+		cacheTTL:       cacheTTL,
+	}
+}
+
+// RecordEvents counts each interaction event in the bucket of the minute it happened in
+func (s *RisingService) RecordEvents(ctx context.Context, events []models.InteractionEvent) error {
+	return recordCounts(ctx, s.redisClient, events, rateBucketKey, s.recentWindow+s.baselineWindow+rateBucketSize)
+}
+
+// TopRising returns a page of the videos whose recent rate exceeds their baseline the most.
+// Blacklisted videos are removed and boosts and demotions multiply the velocity; pins only apply to score leaderboards.
+func (s *RisingService) TopRising(ctx context.Context, offset, limit int) ([]RisingScore, error) {
+	if err := s.refreshUnions(ctx); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.redisClient.ZRevRangeByScoreWithScores(ctx, rateRecentKey, &redis.ZRangeBy{
+		Min:   "(0",
+		Max:   "+inf",
+		Count: risingCandidates,
+	}).Result()
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	members := make([]string, len(candidates))
+	for i, z := range candidates {
+		members[i] = z.Member.(string)
+	}
+	baselines, err := s.redisClient.ZMScore(ctx, rateBaselineKey, members...).Result()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.overrides.ActiveOverrides()
+	if err != nil {
+		return nil, err
+	}
+	blacklisted, multipliers, _ := summarizeOverrides(overrides)
+
+	recentMinutes := s.recentWindow.Minutes()
+	baselineMinutes := s.baselineWindow.Minutes()
+	rising := make([]RisingScore, 0, len(candidates))
+	for i, z := range candidates {
+		if blacklisted[members[i]] {
+			continue
+		}
+		recentRate := z.Score / recentMinutes
+		baselineRate := baselines[i] / baselineMinutes
+		velocity := (recentRate - baselineRate) / (baselineRate + risingPriorRate)
+		if velocity <= 0 {
+			continue
+		}
+		if multiplier, ok := multipliers[members[i]]; ok {
+			velocity *= multiplier
+		}
+		rising = append(rising, RisingScore{
+			VideoID:      members[i],
+			Score:        velocity,
+			RecentRate:   recentRate,
+			BaselineRate: baselineRate,
+		})
+	}
+	sort.SliceStable(rising, func(i, j int) bool {
+		return rising[i].Score > rising[j].Score
+	})
+
+	if offset >= len(rising) {
+		return []RisingScore{}, nil
+	}
+	end := offset + limit
+	if end > len(rising) {
+		end = len(rising)
+	}
+	return rising[offset:end], nil
+}
+
+// refreshUnions rescores the recent and baseline buckets when their cache expired
+func (s *RisingService) refreshUnions(ctx context.Context) error {
+	exists, err := s.redisClient.Exists(ctx, rateRecentKey, rateBaselineKey).Result()
+	if err != nil {
+		return err
+	}
+	if exists == 2 {
+		return nil
+	}
+
+	now := time.Now()
+	recentBuckets := int(s.recentWindow / rateBucketSize)
+	baselineBuckets := int(s.baselineWindow / rateBucketSize)
+	recentKeys := make([]string, recentBuckets)
+	for i := range recentKeys {
+		recentKeys[i] = rateBucketKey(now.Add(-time.Duration(i) * rateBucketSize))
+	}
+	baselineKeys := make([]string, baselineBuckets)
+	for i := range baselineKeys {
+		baselineKeys[i] = rateBucketKey(now.Add(-time.Duration(recentBuckets+i) * rateBucketSize))
+	}
+
+	if err := scoreBuckets(ctx, s.redisClient, s.scorer, recentKeys, rateRecentKey, s.cacheTTL); err != nil {
+		return err
+	}
+	return scoreBuckets(ctx, s.redisClient, s.scorer, baselineKeys, rateBaselineKey, s.cacheTTL)
+}
+
+// rateBucketKey returns the key of the per-minute bucket containing t
+func rateBucketKey(t time.Time) string {
+	return rateBucketPrefix + t.UTC().Truncate(rateBucketSize).Format(rateBucketFmt)
+}
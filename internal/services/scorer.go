@@ -0,0 +1,245 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Scoring strategy names accepted by NewScorer
+const (
+	LinearScoring   = "linear"
+	LogScoring      = "log"
+	WilsonScoring   = "wilson"
+	BayesianScoring = "bayesian"
+)
+
+// Scorer calculates a ranking score from a video's engagement counters.
+// Score must return the same value as Explain(...).Score.
+type Scorer interface {
+	Name() string
+	Score(views, likes, comments int64) float64
+	Explain(views, likes, comments int64) ScoreExplanation
+}
+
+// ScoreExplanation breaks a score down into the inputs and parameters it was computed from
+type ScoreExplanation struct {
+	Strategy   string             `json:"strategy"`
+	Components []ScoreComponent   `json:"components"`
+	Parameters map[string]float64 `json:"parameters,omitempty"`
+	Score      float64            `json:"score"`
+}
+
+// ScoreComponent describes how one counter contributes to a score.
+// Contribution is only set for strategies where the score is a sum of components.
+type ScoreComponent struct {
+	Input        string   `json:"input"`
+	Value        int64    `json:"value"`
+	Weight       float64  `json:"weight,omitempty"`
+	Transform    string   `json:"transform"`
+	Contribution *float64 `json:"contribution,omitempty"`
+}
+
+// NewScorer returns the built-in scorer registered under name.
+// An empty name selects the linear weighted scorer.
+func NewScorer(name string) (Scorer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", LinearScoring:
+		return NewLinearScorer(), nil
+	case LogScoring:
+		return NewLogScorer(), nil
+	case WilsonScoring:
+		return NewWilsonScorer(), nil
+	case BayesianScoring:
+		return NewBayesianScorer(), nil
+	default:
+		return nil, fmt.Errorf("unknown scoring strategy %q", name)
+	}
+}
+
+// LinearScorer sums the counters multiplied by a fixed weight
+type LinearScorer struct {
+	ViewWeight    float64
+	LikeWeight    float64
+	CommentWeight float64
+}
+
+// NewLinearScorer creates a linear scorer with the default weights
+func NewLinearScorer() *LinearScorer {
+	return &LinearScorer{
+		ViewWeight:    1.0,
+		LikeWeight:    2.0,
+		CommentWeight: 3.0,
+	}
+}
+
+func (s *LinearScorer) Name() string {
+	return LinearScoring
+}
+
+func (s *LinearScorer) Score(views, likes, comments int64) float64 {
+	return s.Explain(views, likes, comments).Score
+}
+
+func (s *LinearScorer) Explain(views, likes, comments int64) ScoreExplanation {
+	components := []ScoreComponent{
+		weighted("views", views, s.ViewWeight, "value", float64(views)),
+		weighted("likes", likes, s.LikeWeight, "value", float64(likes)),
+		weighted("comments", comments, s.CommentWeight, "value", float64(comments)),
+	}
+	score := sumContributions(components)
+
+	// Normalize score to be positive
+	if score < 0 {
+		score = 0
+	}
+	return ScoreExplanation{Strategy: s.Name(), Components: components, Score: score}
+}
+
+// LogScorer dampens each counter with log10 so that large videos grow slowly
+type LogScorer struct {
+	ViewWeight    float64
+	LikeWeight    float64
+	CommentWeight float64
+}
+
+// NewLogScorer creates a log-dampened scorer with the default weights
+func NewLogScorer() *LogScorer {
+	return &LogScorer{
+		ViewWeight:    1.0,
+		LikeWeight:    2.0,
+		CommentWeight: 3.0,
+	}
+}
+
+func (s *LogScorer) Name() string {
+	return LogScoring
+}
+
+func (s *LogScorer) Score(views, likes, comments int64) float64 {
+	return s.Explain(views, likes, comments).Score
+}
+
+func (s *LogScorer) Explain(views, likes, comments int64) ScoreExplanation {
+	components := []ScoreComponent{
+		weighted("views", views, s.ViewWeight, "log10(1 + value)", dampen(views)),
+		weighted("likes", likes, s.LikeWeight, "log10(1 + value)", dampen(likes)),
+		weighted("comments", comments, s.CommentWeight, "log10(1 + value)", dampen(comments)),
+	}
+	return ScoreExplanation{Strategy: s.Name(), Components: components, Score: sumContributions(components)}
+}
+
+// WilsonScorer ranks by the lower bound of the Wilson score interval
+// for the proportion of views that resulted in a like
+type WilsonScorer struct {
+	// Z is the quantile of the normal distribution, 1.96 for 95% confidence
+	Z float64
+}
+
+// NewWilsonScorer creates a Wilson scorer with 95% confidence
+func NewWilsonScorer() *WilsonScorer {
+	return &WilsonScorer{Z: 1.96}
+}
+
+func (s *WilsonScorer) Name() string {
+	return WilsonScoring
+}
+
+func (s *WilsonScorer) Score(views, likes, comments int64) float64 {
+	return s.Explain(views, likes, comments).Score
+}
+
+func (s *WilsonScorer) Explain(views, likes, comments int64) ScoreExplanation {
+	explanation := ScoreExplanation{
+		Strategy: s.Name(),
+		Components: []ScoreComponent{
+			{Input: "views", Value: views, Transform: "number of trials"},
+			{Input: "likes", Value: likes, Transform: "successes, clamped to [0, views]"},
+			{Input: "comments", Value: comments, Transform: "ignored"},
+		},
+		Parameters: map[string]float64{"z": s.Z},
+	}
+
+	n := float64(views)
+	if n <= 0 {
+		return explanation
+	}
+	positive := math.Min(math.Max(float64(likes), 0), n)
+	p := positive / n
+	z2 := s.Z * s.Z
+
+	explanation.Score = (p + z2/(2*n) - s.Z*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+	return explanation
+}
+
+// BayesianScorer ranks by the engagement rate (likes and comments per view)
+// pulled towards a prior mean until the video has enough views
+type BayesianScorer struct {
+	PriorMean  float64
+	PriorViews float64
+}
+
+// NewBayesianScorer creates a Bayesian average scorer with the default prior
+func NewBayesianScorer() *BayesianScorer {
+	return &BayesianScorer{
+		PriorMean:  0.05,
+		PriorViews: 100,
+	}
+}
+
+func (s *BayesianScorer) Name() string {
+	return BayesianScoring
+}
+
+func (s *BayesianScorer) Score(views, likes, comments int64) float64 {
+	return s.Explain(views, likes, comments).Score
+}
+
+func (s *BayesianScorer) Explain(views, likes, comments int64) ScoreExplanation {
+	engagements := math.Max(float64(likes+comments), 0)
+	n := math.Max(float64(views), 0)
+	return ScoreExplanation{
+		Strategy: s.Name(),
+		Components: []ScoreComponent{
+			{Input: "views", Value: views, Transform: "added to prior_views in the denominator"},
+			{Input: "likes", Value: likes, Transform: "engagement, added to prior_mean * prior_views in the numerator"},
+			{Input: "comments", Value: comments, Transform: "engagement, added to prior_mean * prior_views in the numerator"},
+		},
+		Parameters: map[string]float64{
+			"prior_mean":  s.PriorMean,
+			"prior_views": s.PriorViews,
+		},
+		Score: (s.PriorMean*s.PriorViews + engagements) / (s.PriorViews + n),
+	}
+}
+
+// weighted builds an additive component whose contribution is the transformed value times weight
+func weighted(input string, value int64, weight float64, transform string, transformed float64) ScoreComponent {
+	contribution := transformed * weight
+	return ScoreComponent{
+		Input:        input,
+		Value:        value,
+		Weight:       weight,
+		Transform:    transform,
+		Contribution: &contribution,
+	}
+}
+
+// sumContributions adds up the contributions of additive components
+func sumContributions(components []ScoreComponent) float64 {
+	var sum float64
+	for _, component := range components {
+		if component.Contribution != nil {
+			sum += *component.Contribution
+		}
+	}
+	return sum
+}
+
+// dampen returns log10(1+n), treating negative counters as zero
+func dampen(n int64) float64 {
+	if n <= 0 {
+		return 0
+	}
+	return math.Log10(1 + float64(n))
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScorers(t *testing.T) {
+	tests := []struct {
+		name     string
+		scorer   Scorer
+		views    int64
+		likes    int64
+		comments int64
+		want     float64
+	}{
+		{"linear weighs views, likes and comments", NewLinearScorer(), 10, 5, 2, 10 + 2*5 + 3*2},
+		{"linear scores an unwatched video zero", NewLinearScorer(), 0, 0, 0, 0},
+		{"linear clamps negative totals to zero", NewLinearScorer(), -10, 0, 0, 0},
+		{"log dampens every counter", NewLogScorer(), 9, 99, 999, 1 + 2*2 + 3*3},
+		{"log treats negative counters as zero", NewLogScorer(), -5, -5, -5, 0},
+		{"wilson scores an unwatched video zero", NewWilsonScorer(), 0, 3, 0, 0},
+		{"wilson scores no likes near zero", NewWilsonScorer(), 100, 0, 0, 0},
+		{"wilson lower bound for half liked", NewWilsonScorer(), 100, 50, 0, 0.4038298},
+		{"wilson clamps likes to views", NewWilsonScorer(), 10, 20, 0, 0.7224598},
+		{"bayesian starts at the prior mean", NewBayesianScorer(), 0, 0, 0, 0.05},
+		{"bayesian pulls the rate towards the prior", NewBayesianScorer(), 100, 10, 5, (5 + 15) / 200.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.scorer.Score(tt.views, tt.likes, tt.comments)
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("Score(%d, %d, %d) = %v, want %v", tt.views, tt.likes, tt.comments, got, tt.want)
+			}
+			if explained := tt.scorer.Explain(tt.views, tt.likes, tt.comments).Score; explained != got {
+				t.Errorf("Explain(...).Score = %v, want Score() = %v", explained, got)
+			}
+		})
+	}
+}
+
+func TestNewScorer(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", LinearScoring, false},
+		{"linear", LinearScoring, false},
+		{" Log ", LogScoring, false},
+		{"wilson", WilsonScoring, false},
+		{"bayesian", BayesianScoring, false},
+		{"random", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := NewScorer(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewScorer(%q) returned %s, want an error", tt.name, scorer.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewScorer(%q) returned error: %v", tt.name, err)
+			}
+			if scorer.Name() != tt.want {
+				t.Errorf("NewScorer(%q).Name() = %q, want %q", tt.name, scorer.Name(), tt.want)
+			}
+		})
+	}
+}
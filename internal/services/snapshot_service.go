@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// snapshotLockKey makes sure a single instance takes each snapshot
+const snapshotLockKey = "video:scores:snapshot:lock"
+
+// SnapshotService periodically persists the all-time leaderboard for historical queries
+type SnapshotService struct {
+	repo        *repositories.SnapshotRepository
+	redisClient *redis.Client
+	overrides   *OverrideService
+	topN        int
+	retention   time.Duration
+}
+
+// NewSnapshotService creates a new snapshot service that records the top topN videos.
+// Snapshots older than retention are deleted, or kept forever when retention is 0.
+func NewSnapshotService(repo *repositories.SnapshotRepository, redisClient *redis.Client, overrides *OverrideService, topN int, retention time.Duration) *SnapshotService {
+	return &SnapshotService{
+		repo:        repo,
+		redisClient: redisClient,
+		overrides:   overrides,
+		topN:        topN,
+		retention:   retention,
+	}
+}
+
+// Run takes a snapshot every interval until ctx is cancelled
+func (s *SnapshotService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Instances whose tickers fire shortly after the one that took the snapshot skip the round
+			err := s.TakeSnapshot(ctx, interval*9/10)
+			if errors.Is(err, ErrLockHeld) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error taking leaderboard snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// TakeSnapshot persists the current top N of the all-time leaderboard with editorial overrides applied
+// and deletes the snapshots past retention.
+// The snapshot lock is left to expire after hold instead of being released, so no other snapshot is taken
+// in the meantime; ErrLockHeld is returned while it is held.
+func (s *SnapshotService) TakeSnapshot(ctx context.Context, hold time.Duration) error {
+	if _, err := acquireLock(ctx, s.redisClient, snapshotLockKey, hold); err != nil {
+		return err
+	}
+
+	// Enough raw entries are read for the snapshot to stay full once blacklisted videos are removed
+	raw, err := s.redisClient.ZRevRangeWithScores(ctx, videoScoresKey, 0, int64(s.topN+trendingCandidates-1)).Result()
+	if err != nil {
+		return err
+	}
+	ranked, err := s.overrides.Apply(ctx, videoScoresKey, raw, s.topN)
+	if err != nil {
+		return err
+	}
+
+	takenAt := time.Now().Truncate(time.Second)
+	entries := make([]models.LeaderboardSnapshot, 0, len(ranked))
+	for _, entry := range ranked {
+		videoID, err := uuid.Parse(entry.VideoID)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, models.LeaderboardSnapshot{
+			TakenAt: takenAt,
+			Rank:    len(entries) + 1,
+			VideoID: videoID,
+			Score:   entry.Score,
+		})
+	}
+	if err := s.repo.CreateBatch(entries); err != nil {
+		return err
+	}
+
+	if s.retention == 0 {
+		return nil
+	}
+	return s.repo.DeleteBefore(takenAt.Add(-s.retention))
+}
+
+// GetLeaderboardAt retrieves the leaderboard as it was recorded at or just before at
+func (s *SnapshotService) GetLeaderboardAt(at time.Time) ([]models.LeaderboardSnapshot, error) {
+	return s.repo.FindLatestAt(at)
+}
+
+// GetRankHistory retrieves the recorded ranks of a video between from and to
+func (s *SnapshotService) GetRankHistory(videoID uuid.UUID, from, to time.Time) ([]models.LeaderboardSnapshot, error) {
+	return s.repo.FindByVideo(videoID, from, to)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trieuvy/video-ranking/internal/models"
+	"github.com/trieuvy/video-ranking/internal/params/response"
+	"github.com/trieuvy/video-ranking/internal/repositories"
+)
+
+// Leaderboard windows served besides the rolling windows
+const (
+	AllTimeWindow = "all"
+	HotWindow     = "hot"
+)
+
+// TrendingQuery selects a page of a leaderboard.
+// Category and Tag scope the all-time leaderboard and cannot be combined with other windows.
+// UserID selects the experiment arm whose all-time leaderboard is served, if any.
+type TrendingQuery struct {
+	Window   string
+	Category string
+	Tag      string
+	UserID   uuid.UUID
+	Offset   int
+	Limit    int
+}
+
+// TrendingService reads leaderboards and hydrates them with video details
+type TrendingService struct {
+	repo                 *repositories.VideoRepository
+	redisClient          *redis.Client
+	hotRankingService    *HotRankingService
+	windowRankingService *WindowRankingService
+	risingService        *RisingService
+	experimentService    *ExperimentService
+	overrides            *OverrideService
+}
+
+// NewTrendingService creates a new trending service
+func NewTrendingService(repo *repositories.VideoRepository, redisClient *redis.Client, hotRankingService *HotRankingService, windowRankingService *WindowRankingService, risingService *RisingService, experimentService *ExperimentService, overrides *OverrideService) *TrendingService {
+	return &TrendingService{
+		repo:                 repo,
+		redisClient:          redisClient,
+		hotRankingService:    hotRankingService,
+		windowRankingService: windowRankingService,
+		risingService:        risingService,
+		experimentService:    experimentService,
+		overrides:            overrides,
+	}
+}
+
+// GetTrending retrieves a page of the leaderboard selected by query with editorial overrides applied.
+// An empty window selects the all-time leaderboard.
+func (s *TrendingService) GetTrending(ctx context.Context, query TrendingQuery) ([]response.TrendingVideo, error) {
+	if query.Category == "" && query.Tag == "" && (query.Window == "" || query.Window == AllTimeWindow) {
+		assignment, err := s.experimentService.Assign(query.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if assignment != nil {
+			ranked, err := s.experimentService.ArmScores(ctx, assignment, query.Offset, query.Limit)
+			if err != nil {
+				return nil, err
+			}
+			return s.hydrate(ranked, query.Offset)
+		}
+	}
+
+	// Enough raw entries are read for the page to stay full once blacklisted videos are removed
+	key, raw, err := s.rawScores(ctx, query, query.Offset+query.Limit+trendingCandidates)
+	if err != nil {
+		return nil, err
+	}
+	ranked, err := s.overrides.Apply(ctx, key, raw, query.Offset+query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if query.Offset >= len(ranked) {
+		return s.hydrate(nil, query.Offset)
+	}
+	return s.hydrate(ranked[query.Offset:], query.Offset)
+}
+
+// GetRising retrieves a page of the videos whose engagement rate is spiking
+func (s *TrendingService) GetRising(ctx context.Context, offset, limit int) ([]response.RisingVideo, error) {
+	scores, err := s.risingService.TopRising(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, len(scores))
+	for i, score := range scores {
+		members[i] = score.VideoID
+	}
+	byID, err := s.loadVideos(members)
+	if err != nil {
+		return nil, err
+	}
+
+	risingVideos := make([]response.RisingVideo, 0, len(scores))
+	for _, score := range scores {
+		id, err := uuid.Parse(score.VideoID)
+		if err != nil {
+			continue
+		}
+		video, ok := byID[id]
+		if !ok {
+			continue
+		}
+		risingVideos = append(risingVideos, response.RisingVideo{
+			TrendingVideo: response.NewTrendingVideo(offset+len(risingVideos)+1, score.Score, video),
+			RecentRate:    score.RecentRate,
+			BaselineRate:  score.BaselineRate,
+		})
+	}
+	return risingVideos, nil
+}
+
+// rawScores returns the key of the leaderboard selected by query and its first count raw sorted set entries
+func (s *TrendingService) rawScores(ctx context.Context, query TrendingQuery, count int) (string, []redis.Z, error) {
+	var key string
+	switch {
+	case query.Category != "":
+		key = tagScoresKey(models.Tag{Kind: models.CategoryTag, Name: query.Category})
+	case query.Tag != "":
+		key = tagScoresKey(models.Tag{Kind: models.KeywordTag, Name: query.Tag})
+	case query.Window == "" || query.Window == AllTimeWindow:
+		key = videoScoresKey
+	case query.Window == HotWindow:
+		raw, err := s.hotRankingService.TopScores(ctx, 0, count)
+		return hotScoresKey, raw, err
+	default:
+		key, err := s.windowRankingService.unionKey(ctx, query.Window)
+		if err != nil {
+			return "", nil, err
+		}
+		raw, err := s.windowRankingService.TopScores(ctx, query.Window, 0, count)
+		return key, raw, err
+	}
+	raw, err := s.redisClient.ZRevRangeWithScores(ctx, key, 0, int64(count-1)).Result()
+	return key, raw, err
+}
+
+// hydrate loads the videos of the leaderboard entries, keeping their order.
+// Entries whose video no longer exists are skipped and the rest are ranked without gaps.
+func (s *TrendingService) hydrate(entries []RankedVideo, offset int) ([]response.TrendingVideo, error) {
+	trendingVideos := make([]response.TrendingVideo, 0, len(entries))
+	if len(entries) == 0 {
+		return trendingVideos, nil
+	}
+
+	members := make([]string, len(entries))
+	for i, entry := range entries {
+		members[i] = entry.VideoID
+	}
+	byID, err := s.loadVideos(members)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		id, err := uuid.Parse(entry.VideoID)
+		if err != nil {
+			continue
+		}
+		video, ok := byID[id]
+		if !ok {
+			continue
+		}
+		trendingVideo := response.NewTrendingVideo(offset+len(trendingVideos)+1, entry.Score, video)
+		trendingVideo.Pinned = entry.Pinned
+		trendingVideos = append(trendingVideos, trendingVideo)
+	}
+	return trendingVideos, nil
+}
+
+// loadVideos loads the videos of sorted set members indexed by ID
+func (s *TrendingService) loadVideos(members []string) (map[uuid.UUID]models.Video, error) {
+	videos, err := s.repo.FindByIDs(parseVideoIDs(members))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Video, len(videos))
+	for _, video := range videos {
+		byID[video.ID] = video
+	}
+	return byID, nil
+}
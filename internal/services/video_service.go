@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,29 +13,57 @@ import (
 	"github.com/trieuvy/video-ranking/internal/models"
 	"github.com/trieuvy/video-ranking/internal/repositories"
 	"github.com/trieuvy/video-ranking/internal/ws"
+	"gorm.io/gorm"
+)
+
+const (
+	// videoScoresKey holds the all-time score of every video
+	videoScoresKey = "video:scores"
+	// trendingCandidates is how many raw entries are read before overrides are applied to the top 10
+	trendingCandidates = 50
+	// publishedTrendingKey holds the last published top 10 so rank changes survive a restart
+	publishedTrendingKey = "trending:published"
 )
 
 // VideoService handles business logic for videos
 type VideoService struct {
 	repo        *repositories.VideoRepository
+	tagRepo     *repositories.TagRepository
 	redisClient *redis.Client
+	scorer      Scorer
+	overrides   *OverrideService
+
+	// notifyLock serializes broadcasts so rank changes are computed against the last published leaderboard
+	notifyLock    sync.Mutex
+	lastPublished map[string]rankedEntry
 }
 
 // NewVideoService creates a new video service
-func NewVideoService(repo *repositories.VideoRepository, redisClient *redis.Client) *VideoService {
+func NewVideoService(repo *repositories.VideoRepository, tagRepo *repositories.TagRepository, redisClient *redis.Client, scorer Scorer, overrides *OverrideService) *VideoService {
 	return &VideoService{
 		repo:        repo,
+		tagRepo:     tagRepo,
 		redisClient: redisClient,
+		scorer:      scorer,
+		overrides:   overrides,
 	}
 }
 
-// CreateVideo creates a new video
-func (s *VideoService) CreateVideo(video *models.Video) error {
-	err := s.repo.Create(video)
+// Scorer returns the scoring strategy used to rank videos
+func (s *VideoService) Scorer() Scorer {
+	return s.scorer
+}
+
+// CreateVideo creates a new video with the given categories and tags.
+// The video and its tags are saved in one transaction so a failure leaves neither behind.
+// It joins the category and tag leaderboards once it is first scored.
+func (s *VideoService) CreateVideo(video *models.Video, categories, tags []string) error {
+	videoTags, err := s.findOrCreateTags(categories, tags)
 	if err != nil {
 		return err
 	}
-	return nil
+	video.Tags = videoTags
+	return s.repo.Create(video)
 }
 
 // GetVideo retrieves a video by ID
@@ -41,6 +71,11 @@ func (s *VideoService) GetVideo(id uuid.UUID) (*models.Video, error) {
 	return s.repo.FindByID(id)
 }
 
+// GetVideoWithTags retrieves a video by ID together with its categories and tags
+func (s *VideoService) GetVideoWithTags(id uuid.UUID) (*models.Video, error) {
+	return s.repo.FindByIDWithTags(id)
+}
+
 // UpdateVideo updates an existing video
 func (s *VideoService) UpdateVideo(video *models.Video) error {
 	return s.repo.Update(video)
@@ -48,29 +83,61 @@ func (s *VideoService) UpdateVideo(video *models.Video) error {
 
 // DeleteVideo removes a video
 func (s *VideoService) DeleteVideo(id uuid.UUID) error {
-	err := s.repo.Delete(id)
+	video, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Delete(id)
 	if err != nil {
 		return err
 	}
 	// Remove video from Redis
 	ctx := context.Background()
-	err = s.redisClient.ZRem(ctx, "video:scores", id.String()).Err()
+	keys, err := videoMemberKeys(ctx, s.redisClient)
 	if err != nil {
-		log.Printf("Error removing video from Redis: %v", err)
 		return err
 	}
-	trendingVideos, err := s.GetTop10TrendingVideos(ctx)
+	pipe := s.redisClient.TxPipeline()
+	removeVideoScore(ctx, pipe, id, video.CreatedBy)
+	for _, key := range keys {
+		pipe.ZRem(ctx, key, id.String())
+	}
+	_, err = pipe.Exec(ctx)
 	if err != nil {
-		log.Printf("Error getting top trending videos: %v", err)
+		log.Printf("Error removing video from Redis: %v", err)
 		return err
 	}
+	return s.NotifyTrending(ctx)
+}
 
-	jsonData, err := PrepareVideoData(trendingVideos)
-	if err != nil {
-		log.Printf("Error preparing video data: %v", err)
-		return err
+// videoMemberKeys returns the key of every sorted set whose members are video IDs: the all-time, hot, category, tag,
+// window and experiment arm leaderboards, the active videos and the rising buckets.
+// Keys are scanned rather than derived from a video so sets left behind by removed tags or stopped experiments are found too.
+func videoMemberKeys(ctx context.Context, redisClient *redis.Client) ([]string, error) {
+	keys := []string{videoScoresKey, hotScoresKey, activeVideosKey, rateRecentKey, rateBaselineKey}
+	patterns := []string{
+		tagScoresPrefix + string(models.CategoryTag) + ":*",
+		tagScoresPrefix + string(models.KeywordTag) + ":*",
+		windowBucketPrefix + "*",
+		windowUnionPrefix + "*",
+		rateBucketPrefix + "*",
+		experimentPrefix + "*:arm:*:scores",
+	}
+	for _, pattern := range patterns {
+		var cursor uint64
+		for {
+			batch, next, err := redisClient.Scan(ctx, cursor, pattern, 100).Result()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, batch...)
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
 	}
-	return SendNotification(s.redisClient, "trending_videos", string(jsonData))
+	return keys, nil
 }
 
 // GetTopViewedVideosByUser retrieves the top N highest-scoring videos viewed by a specific user
@@ -126,100 +193,379 @@ func (s *VideoService) ChangeCommentsAmount(videoID uuid.UUID, step int) error {
 
 // UpdateAndNotifyRanking updates the ranking of a video and notifies clients
 func (s *VideoService) UpdateAndNotifyRanking(ctx context.Context, videoID uuid.UUID) error {
-
-	video, err := s.GetVideo(videoID)
+	video, err := s.UpdateRanking(ctx, videoID)
 	if err != nil {
 		return err
 	}
+	if err := s.NotifyRankings(ctx, video.Tags); err != nil {
+		return err
+	}
+	return s.NotifyVideos(ctx, []models.Video{*video})
+}
+
+// ApplyEvents applies the counter changes of a video's events without rescoring it.
+// Events already applied by an earlier delivery are skipped; it returns the events that were applied.
+func (s *VideoService) ApplyEvents(videoID uuid.UUID, events []models.InteractionEvent) ([]models.InteractionEvent, error) {
+	return s.repo.ApplyEvents(videoID, events)
+}
+
+// NotifyRankings publishes the all-time leaderboard and the leaderboards of categories and tags
+func (s *VideoService) NotifyRankings(ctx context.Context, tags []models.Tag) error {
+	if err := s.NotifyTrending(ctx); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := s.NotifyTagTrending(ctx, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateRanking rescores a video and stores the score in every leaderboard it belongs to without notifying clients.
+// It returns the rescored video so the caller can notify its categories, its counters and its creator.
+func (s *VideoService) UpdateRanking(ctx context.Context, videoID uuid.UUID) (*models.Video, error) {
+	video, err := s.repo.FindByIDWithTags(videoID)
+	if err != nil {
+		return nil, err
+	}
 
-	newScore := CalculateEngagementScore(video.Views, video.Likes, video.Comments)
+	newScore := s.scorer.Score(video.Views, video.Likes, video.Comments)
 
 	err = s.UpdateVideoScore(videoID, newScore)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.redisClient.ZAdd(ctx, "video:scores", &redis.Z{
-		Score:  newScore,
+	pipe := s.redisClient.TxPipeline()
+	setVideoScore(ctx, pipe, videoID, video.CreatedBy, newScore)
+	// Mark the video as active so the hot ranking keeps recomputing it
+	pipe.ZAdd(ctx, activeVideosKey, &redis.Z{
+		Score:  float64(time.Now().Unix()),
 		Member: videoID.String(),
-	}).Err()
+	})
+	for _, tag := range video.Tags {
+		pipe.ZAdd(ctx, tagScoresKey(tag), &redis.Z{
+			Score:  newScore,
+			Member: videoID.String(),
+		})
+	}
+	_, err = pipe.Exec(ctx)
 	if err != nil {
 		log.Printf("Error updating Redis score: %v", err)
+		return nil, err
+	}
+	video.Score = newScore
+	return video, nil
+}
+
+// videoScoreScript stores or, when ARGV[3] is empty, removes the all-time score of video ARGV[1] and moves its
+// creator ARGV[2] by the change, removing the creator once their score drops to ARGV[4] or below.
+// The change is taken from the score being replaced in the same script so concurrent updates of a creator's videos
+// cannot lose or double count one another.
+var videoScoreScript = redis.NewScript(`
+local old = tonumber(redis.call("ZSCORE", KEYS[1], ARGV[1])) or 0
+local new = 0
+if ARGV[3] == "" then
+	redis.call("ZREM", KEYS[1], ARGV[1])
+else
+	new = tonumber(ARGV[3])
+	redis.call("ZADD", KEYS[1], new, ARGV[1])
+end
+if ARGV[2] == "" then
+	return 0
+end
+local score = tonumber(redis.call("ZINCRBY", KEYS[2], new - old, ARGV[2]))
+if score <= tonumber(ARGV[4]) then
+	redis.call("ZREM", KEYS[2], ARGV[2])
+end
+return 0`)
+
+// setVideoScore queues storing the all-time score of a video and adding its change to the score of its creator
+func setVideoScore(ctx context.Context, pipe redis.Pipeliner, videoID, creatorID uuid.UUID, score float64) {
+	runVideoScoreScript(ctx, pipe, videoID, creatorID, score)
+}
+
+// removeVideoScore queues removing a video from the all-time leaderboard and its score from the score of its creator
+func removeVideoScore(ctx context.Context, pipe redis.Pipeliner, videoID, creatorID uuid.UUID) {
+	runVideoScoreScript(ctx, pipe, videoID, creatorID, "")
+}
+
+// runVideoScoreScript queues videoScoreScript, score being a float64 or "" to remove the video
+func runVideoScoreScript(ctx context.Context, pipe redis.Pipeliner, videoID, creatorID uuid.UUID, score interface{}) {
+	creator := ""
+	if creatorID != uuid.Nil {
+		creator = creatorID.String()
+	}
+	// Eval rather than Run since a missing script is only reported once the pipeline is executed
+	videoScoreScript.Eval(ctx, pipe, []string{videoScoresKey, creatorScoresKey}, videoID.String(), creator, score, scoreTolerance)
+}
+
+// NotifyVideos publishes the live counters of videos and the stats of their creators
+func (s *VideoService) NotifyVideos(ctx context.Context, videos []models.Video) error {
+	updated := time.Now().Format(time.RFC3339)
+	seen := make(map[uuid.UUID]bool)
+	var creators []uuid.UUID
+	for _, video := range videos {
+		update := map[string]interface{}{
+			"type":     "video_counters",
+			"video_id": video.ID.String(),
+			"likes":    video.Likes,
+			"views":    video.Views,
+			"comments": video.Comments,
+			"score":    video.Score,
+			"updated":  updated,
+		}
+		jsonData, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+		if err := SendNotification(s.redisClient, "video_counters:"+video.ID.String(), ws.VideoTopic(video.ID), string(jsonData)); err != nil {
+			return err
+		}
+		if video.CreatedBy != uuid.Nil && !seen[video.CreatedBy] {
+			seen[video.CreatedBy] = true
+			creators = append(creators, video.CreatedBy)
+		}
+	}
+	if len(creators) == 0 {
+		return nil
+	}
+
+	pipe := s.redisClient.Pipeline()
+	scores := make([]*redis.FloatCmd, len(creators))
+	ranks := make([]*redis.IntCmd, len(creators))
+	for i, creator := range creators {
+		scores[i] = pipe.ZScore(ctx, creatorScoresKey, creator.String())
+		ranks[i] = pipe.ZRevRank(ctx, creatorScoresKey, creator.String())
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
 		return err
 	}
+	for i, creator := range creators {
+		score, err := scores[i].Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		rank, err := ranks[i].Result()
+		if err != nil {
+			return err
+		}
+		update := map[string]interface{}{
+			"type":       "creator_stats",
+			"creator_id": creator.String(),
+			"score":      score,
+			"rank":       rank + 1,
+			"updated":    updated,
+		}
+		jsonData, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+		if err := SendNotification(s.redisClient, "creator_stats:"+creator.String(), ws.CreatorTopic(creator), string(jsonData)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyTrending publishes the current top 10 with the rank changes since the last broadcast
+func (s *VideoService) NotifyTrending(ctx context.Context) error {
+	s.notifyLock.Lock()
+	defer s.notifyLock.Unlock()
 
 	trendingVideos, err := s.GetTop10TrendingVideos(ctx)
 	if err != nil {
 		log.Printf("Error getting top trending videos: %v", err)
 		return err
 	}
+	if s.lastPublished == nil {
+		// After a restart, compare with what the previous process published instead of marking every entry new
+		if s.lastPublished, err = s.loadPublished(ctx); err != nil {
+			log.Printf("Error loading the last published trending videos: %v", err)
+		}
+	}
+	dropped, snapshot := annotateRankChanges(s.lastPublished, trendingVideos)
 
-	jsonData, err := PrepareVideoData(trendingVideos)
+	jsonData, err := PrepareVideoData(trendingVideos, dropped)
 	if err != nil {
 		log.Printf("Error preparing video data: %v", err)
 		return err
 	}
-	return SendNotification(s.redisClient, "trending_videos", string(jsonData))
+	// Clients assigned to an experiment arm receive their arm's leaderboard instead
+	if err := SendGroupNotification(s.redisClient, "trending_videos", ws.TrendingTopic, "", string(jsonData)); err != nil {
+		return err
+	}
+	s.lastPublished = snapshot
+	if err := s.savePublished(ctx, snapshot); err != nil {
+		log.Printf("Error saving the published trending videos: %v", err)
+	}
+	return nil
 }
-// CalculateEngagementScore calculates engagement score
-func CalculateEngagementScore(views, likes, comments int64) float64 {
-	// Weights for different engagement metrics
-	const (
-		viewWeight    = 1.0
-		likeWeight    = 2.0
-		commentWeight = 3.0
-	)
 
-	// Calculate base engagement score
-	engagementScore := float64(views)*viewWeight +
-		float64(likes)*likeWeight +
-		float64(comments)*commentWeight
+// publishedEntry is the stored form of a rankedEntry
+type publishedEntry struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+}
 
-	// Normalize score to be positive
-	if engagementScore < 0 {
-		engagementScore = 0
+// loadPublished reads the last published top 10, which is empty when none was stored
+func (s *VideoService) loadPublished(ctx context.Context) (map[string]rankedEntry, error) {
+	data, err := s.redisClient.Get(ctx, publishedTrendingKey).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored map[string]publishedEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
 	}
+	published := make(map[string]rankedEntry, len(stored))
+	for videoID, entry := range stored {
+		published[videoID] = rankedEntry{rank: entry.Rank, score: entry.Score}
+	}
+	return published, nil
+}
 
-	return engagementScore
+// savePublished stores the published top 10 for the next process to compare with
+func (s *VideoService) savePublished(ctx context.Context, published map[string]rankedEntry) error {
+	stored := make(map[string]publishedEntry, len(published))
+	for videoID, entry := range published {
+		stored[videoID] = publishedEntry{Rank: entry.rank, Score: entry.score}
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return s.redisClient.Set(ctx, publishedTrendingKey, data, 0).Err()
 }
 
+// RunOverrideExpiry republishes the leaderboards every interval if an override expired since the last check,
+// since an expiring override changes the leaderboards without any interaction being recorded
+func (s *VideoService) RunOverrideExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	checked := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.notifyExpiredOverrides(ctx, checked, now); err != nil {
+				log.Printf("Error notifying expired overrides: %v", err)
+				continue
+			}
+			checked = now
+		}
+	}
+}
+
+// notifyExpiredOverrides publishes the all-time leaderboard and the leaderboards of the categories of
+// the videos whose overrides expired after from and no later than to
+func (s *VideoService) notifyExpiredOverrides(ctx context.Context, from, to time.Time) error {
+	expired, err := s.overrides.ExpiredBetween(from, to)
+	if err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var tags []models.Tag
+	for _, override := range expired {
+		video, err := s.repo.FindByIDWithTags(override.VideoID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		tags = appendNewTags(tags, seen, video)
+	}
+	return s.NotifyRankings(ctx, tags)
+}
+
+// CalculateEngagementScore calculates engagement score with the default linear weights
+func CalculateEngagementScore(views, likes, comments int64) float64 {
+	return NewLinearScorer().Score(views, likes, comments)
+}
+
+// GetTop10TrendingVideos retrieves the top 10 of the all-time leaderboard with editorial overrides applied
 func (s *VideoService) GetTop10TrendingVideos(ctx context.Context) ([]map[string]interface{}, error) {
+	return s.getTop10(ctx, videoScoresKey)
+}
+
+// getTop10 retrieves the top 10 of the leaderboard stored at key with editorial overrides applied
+func (s *VideoService) getTop10(ctx context.Context, key string) ([]map[string]interface{}, error) {
 	var trendingVideos []map[string]interface{}
-	results, err := s.redisClient.ZRevRangeWithScores(ctx, "video:scores", 0, 9).Result()
+	results, err := s.redisClient.ZRevRangeWithScores(ctx, key, 0, trendingCandidates-1).Result()
 	if err != nil {
 		return trendingVideos, err
 	}
 
-	if len(results) == 0 {
-		return trendingVideos, nil
+	ranked, err := s.overrides.Apply(ctx, key, results, 10)
+	if err != nil {
+		return trendingVideos, err
 	}
+	return rankedVideoData(ranked), nil
+}
 
-	for i, z := range results {
+// rankedVideoData converts leaderboard entries into their broadcast form, flagging pins and adjusted scores
+func rankedVideoData(ranked []RankedVideo) []map[string]interface{} {
+	var trendingVideos []map[string]interface{}
+	for i, entry := range ranked {
 		videoData := map[string]interface{}{
 			"rank":     i + 1,
-			"video_id": z.Member.(string),
-			"score":    z.Score,
+			"video_id": entry.VideoID,
+			"score":    entry.Score,
+		}
+		if entry.Pinned {
+			videoData["pinned"] = true
+		}
+		if entry.Multiplier != 1 {
+			videoData["raw_score"] = entry.RawScore
+			videoData["multiplier"] = entry.Multiplier
 		}
 		trendingVideos = append(trendingVideos, videoData)
 	}
-	return trendingVideos, nil
+	return trendingVideos
+}
+
+// SendNotification publishes a message on channel and delivers it to the websocket clients subscribed to topic
+func SendNotification(redisClient *redis.Client, channel string, topic string, message string) error {
+	ctx := context.Background()
+	err := redisClient.Publish(ctx, channel, message).Err()
+	if err != nil {
+		log.Printf("Error publishing message to Redis: %v", err)
+		return err
+	}
+	ws.Publish(topic, []byte(message))
+	return nil
 }
 
-func SendNotification(redisClient *redis.Client, channel string, message string) error {
+// SendGroupNotification publishes a message on channel and delivers it to the websocket clients of group subscribed to topic
+func SendGroupNotification(redisClient *redis.Client, channel string, topic string, group string, message string) error {
 	ctx := context.Background()
 	err := redisClient.Publish(ctx, channel, message).Err()
 	if err != nil {
 		log.Printf("Error publishing message to Redis: %v", err)
 		return err
 	}
-	ws.Broadcast([]byte(message))
+	ws.PublishGroup(topic, group, []byte(message))
 	return nil
 }
 
-func PrepareVideoData(trendingVideos []map[string]interface{}) ([]byte, error) {
+func PrepareVideoData(trendingVideos []map[string]interface{}, dropped []map[string]interface{}) ([]byte, error) {
 	update := map[string]interface{}{
 		"type":    "trending_videos",
 		"videos":  trendingVideos,
+		"dropped": dropped,
 		"updated": time.Now().Format(time.RFC3339),
 	}
 	return json.Marshal(update)
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/trieuvy/video-ranking/internal/models"
+)
+
+const (
+	// windowBucketPrefix prefixes the hourly sorted sets counting each interaction type
+	windowBucketPrefix = "video:scores:hour:"
+	// windowUnionPrefix prefixes the cached union of the buckets of a window
+	windowUnionPrefix = "video:scores:window:"
+	windowBucketSize  = time.Hour
+	windowBucketFmt   = "2006010215"
+)
+
+// ErrUnknownWindow is returned when a leaderboard window is not supported
+var ErrUnknownWindow = errors.New("unknown trending window")
+
+// trendingWindows maps each supported window to the number of hourly buckets it spans
+var trendingWindows = map[string]int{
+	"1h":  1,
+	"24h": 24,
+	"7d":  7 * 24,
+}
+
+// WindowRankingService maintains rolling-window leaderboards from hourly buckets
+type WindowRankingService struct {
+	redisClient *redis.Client
+	scorer      Scorer
+	unionTTL    time.Duration
+}
+
+// NewWindowRankingService creates a new window ranking service ranking videos with scorer
+// by the interactions they received within each window.
+// The scores of a window are cached for unionTTL.
+func NewWindowRankingService(redisClient *redis.Client, scorer Scorer, unionTTL time.Duration) *WindowRankingService {
+	return &WindowRankingService{
+		redisClient: redisClient,
+		scorer:      scorer,
+		unionTTL:    unionTTL,
+	}
+}
+
+// IsWindow reports whether window is a supported rolling window
+func IsWindow(window string) bool {
+	_, ok := trendingWindows[window]
+	return ok
+}
+
+// RecordEvents counts each interaction event in the bucket of the hour it happened in
+func (s *WindowRankingService) RecordEvents(ctx context.Context, events []models.InteractionEvent) error {
+	// Keep each bucket as long as the widest window needs it
+	return recordCounts(ctx, s.redisClient, events, windowBucketKey, time.Duration(trendingWindows["7d"]+1)*windowBucketSize)
+}
+
+// TopScores returns a page of the videos with the highest score within window
+func (s *WindowRankingService) TopScores(ctx context.Context, window string, offset, limit int) ([]redis.Z, error) {
+	key, err := s.unionKey(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	return s.redisClient.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:    "(0",
+		Max:    "+inf",
+		Offset: int64(offset),
+		Count:  int64(limit),
+	}).Result()
+}
+
+// unionKey returns the key holding the scores of window, rescoring the sum of its buckets when the cache expired
+func (s *WindowRankingService) unionKey(ctx context.Context, window string) (string, error) {
+	buckets, ok := trendingWindows[window]
+	if !ok {
+		return "", ErrUnknownWindow
+	}
+	dest := windowUnionPrefix + window
+
+	exists, err := s.redisClient.Exists(ctx, dest).Result()
+	if err != nil {
+		return "", err
+	}
+	if exists > 0 {
+		return dest, nil
+	}
+
+	now := time.Now()
+	keys := make([]string, buckets)
+	for i := range keys {
+		keys[i] = windowBucketKey(now.Add(-time.Duration(i) * windowBucketSize))
+	}
+	if err := scoreBuckets(ctx, s.redisClient, s.scorer, keys, dest, s.unionTTL); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// windowBucketKey returns the key of the hourly bucket containing t
+func windowBucketKey(t time.Time) string {
+	return windowBucketPrefix + t.UTC().Truncate(windowBucketSize).Format(windowBucketFmt)
+}
@@ -1,63 +1,300 @@
 package ws
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// maxTopics caps how many topics a client may subscribe to
+	maxTopics = 100
+	// sendBuffer is how many messages may wait for a client's writer before the client is evicted
+	sendBuffer = 256
+	// writeWait bounds how long writing a message to a client may take
+	writeWait = 10 * time.Second
+	// pongWait is how long a client may stay silent before its connection is considered dead
+	pongWait = 60 * time.Second
+	// pingPeriod is how often clients are pinged, shorter than pongWait so a live client always answers in time
+	pingPeriod = pongWait * 9 / 10
+	// maxMessageSize caps the size of the subscription requests a client may send
+	maxMessageSize = 4096
+)
+
+// errTooManyTopics is replied to a client subscribing to more than maxTopics topics
+var errTooManyTopics = errors.New("too many subscriptions")
+
 type Client struct {
 	Conn *websocket.Conn
+	// Group is the experiment arm the client is assigned to, empty for the default feed
+	Group string
+
+	// topics are the topics the client is subscribed to, guarded by the hub lock
+	topics map[string]bool
+	// send buffers the messages waiting to be written by the client's writer
+	send chan []byte
+	// done is closed to stop the writer and close the connection
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// enqueue hands a message to the client's writer without blocking.
+// A client whose buffer is full cannot keep up and is disconnected rather than allowed to stall publishers.
+func (c *Client) enqueue(message []byte) {
+	select {
+	case <-c.done:
+		// The client is being disconnected
+		return
+	default:
+	}
+	select {
+	case c.send <- message:
+	default:
+		log.Printf("Disconnecting websocket client %s: %d messages pending", c.Conn.RemoteAddr(), len(c.send))
+		c.close()
+	}
+}
+
+// close stops the client's writer, which then closes the connection and ends the read loop
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// writePump writes the queued messages and the keepalive pings of a client. It is the only writer of the connection.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
+	for {
+		select {
+		case message := <-c.send:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				log.Println("Error writing message:", err)
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+			return
+		}
+	}
 }
 
 type Hub struct {
 	clients map[*Client]bool
-	lock    sync.RWMutex
+	// topics indexes the subscribers of every topic
+	topics map[string]map[*Client]bool
+	lock   sync.RWMutex
 }
 
 var hub = &Hub{
 	clients: make(map[*Client]bool),
+	topics:  make(map[string]map[*Client]bool),
 }
 
-func RegisterClient(conn *websocket.Conn) *Client {
-	client := &Client{Conn: conn}
+func RegisterClient(conn *websocket.Conn, group string) *Client {
+	client := &Client{
+		Conn:   conn,
+		Group:  group,
+		topics: make(map[string]bool),
+		send:   make(chan []byte, sendBuffer),
+		done:   make(chan struct{}),
+	}
 	hub.lock.Lock()
 	hub.clients[client] = true
 	hub.lock.Unlock()
+	go client.writePump()
 	return client
 }
 
 func UnregisterClient(client *Client) {
 	hub.lock.Lock()
 	delete(hub.clients, client)
+	for topic := range client.topics {
+		hub.unsubscribe(client, topic)
+	}
 	hub.lock.Unlock()
+	client.close()
 }
 
-func Broadcast(message []byte) {
+// Subscribe adds a client to the subscribers of a topic and returns the normalized topic it was subscribed to
+func Subscribe(client *Client, topic string) (string, error) {
+	topic, err := NormalizeTopic(topic)
+	if err != nil {
+		return "", err
+	}
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	if client.topics[topic] {
+		return topic, nil
+	}
+	if len(client.topics) >= maxTopics {
+		return "", errTooManyTopics
+	}
+	client.topics[topic] = true
+	subscribers, ok := hub.topics[topic]
+	if !ok {
+		subscribers = make(map[*Client]bool)
+		hub.topics[topic] = subscribers
+	}
+	subscribers[client] = true
+	return topic, nil
+}
+
+// Unsubscribe removes a client from the subscribers of a topic and returns the normalized topic.
+// An invalid topic has no subscribers, so it is returned as it is.
+func Unsubscribe(client *Client, topic string) string {
+	if normalized, err := NormalizeTopic(topic); err == nil {
+		topic = normalized
+	}
+	hub.lock.Lock()
+	hub.unsubscribe(client, topic)
+	hub.lock.Unlock()
+	return topic
+}
+
+// unsubscribe removes a client from a topic. The caller must hold the hub lock.
+func (h *Hub) unsubscribe(client *Client, topic string) {
+	delete(client.topics, topic)
+	if subscribers, ok := h.topics[topic]; ok {
+		delete(subscribers, client)
+		if len(subscribers) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Publish sends a message to the subscribers of a topic
+func Publish(topic string, message []byte) {
 	hub.lock.RLock()
 	defer hub.lock.RUnlock()
-	for client := range hub.clients {
-		client.Conn.WriteMessage(websocket.TextMessage, message)
+	for client := range hub.topics[topic] {
+		client.enqueue(message)
 	}
 }
 
-func WsHandler(w http.ResponseWriter, r *http.Request) {
-	conn, err := websocket.Upgrade(w, r, nil, 1024, 1024)
-	if err != nil {
-		http.Error(w, "Failed to upgrade to websocket", http.StatusInternalServerError)
-		return
+// PublishGroup sends a message to the subscribers of a topic that belong to a group
+func PublishGroup(topic string, group string, message []byte) {
+	hub.lock.RLock()
+	defer hub.lock.RUnlock()
+	for client := range hub.topics[topic] {
+		if client.Group == group {
+			client.enqueue(message)
+		}
 	}
-	client := RegisterClient(conn)
-	defer UnregisterClient(client)
+}
 
-	for {
-		_, message, err := conn.ReadMessage()
+// HasSubscribers reports whether any client is subscribed to a topic
+func HasSubscribers(topic string) bool {
+	hub.lock.RLock()
+	defer hub.lock.RUnlock()
+	return len(hub.topics[topic]) > 0
+}
+
+// ClearGroups moves every client back to the default feed
+func ClearGroups() {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	for client := range hub.clients {
+		client.Group = ""
+	}
+}
+
+// NewWsHandler creates the websocket endpoint handler.
+// groupOf assigns each connection to a group when it is opened.
+// Connections are subscribed to the comma-separated topics query parameter, or to the trending topic when it is empty,
+// and then change their subscriptions by sending {"action": "subscribe" | "unsubscribe", "topic": "..."} messages.
+func NewWsHandler(groupOf func(r *http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, nil, 1024, 1024)
 		if err != nil {
-			log.Println("Error reading message:", err)
-			break
+			http.Error(w, "Failed to upgrade to websocket", http.StatusInternalServerError)
+			return
+		}
+		client := RegisterClient(conn, groupOf(r))
+		defer UnregisterClient(client)
+
+		// A client that stops answering pings is dropped once its read deadline passes
+		conn.SetReadLimit(maxMessageSize)
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+
+		topics := []string{TrendingTopic}
+		if value := r.URL.Query().Get("topics"); value != "" {
+			topics = strings.Split(value, ",")
+		}
+		for _, topic := range topics {
+			client.subscribe(topic)
 		}
-		Broadcast(message)
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				log.Println("Error reading message:", err)
+				break
+			}
+			client.handle(message)
+		}
+	}
+}
+
+// handle applies a subscription request sent by the client
+func (c *Client) handle(message []byte) {
+	var request Request
+	if err := json.Unmarshal(message, &request); err != nil {
+		c.reply(ErrorReply, "", errors.New("invalid request"))
+		return
+	}
+	switch request.Action {
+	case SubscribeAction:
+		c.subscribe(request.Topic)
+	case UnsubscribeAction:
+		c.reply(UnsubscribedReply, Unsubscribe(c, request.Topic), nil)
+	default:
+		c.reply(ErrorReply, request.Topic, errors.New("unknown action"))
+	}
+}
+
+// subscribe subscribes the client to a topic and acknowledges it with the normalized topic,
+// or with the topic as sent when it is rejected
+func (c *Client) subscribe(topic string) {
+	normalized, err := Subscribe(c, topic)
+	if err != nil {
+		normalized = topic
+	}
+	c.reply(SubscribedReply, normalized, err)
+}
+
+// reply acknowledges a request with replyType, or reports why it failed
+func (c *Client) reply(replyType, topic string, err error) {
+	reply := Reply{Type: replyType, Topic: topic}
+	if err != nil {
+		reply = Reply{Type: ErrorReply, Topic: topic, Error: err.Error()}
+	}
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Println("Error encoding reply:", err)
+		return
 	}
+	c.enqueue(data)
 }
@@ -0,0 +1,108 @@
+package ws
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Topics clients can subscribe to
+const (
+	// TrendingTopic carries the all-time leaderboard, or the leaderboard of the client's experiment arm
+	TrendingTopic = "trending"
+	// categoryTopicPrefix prefixes the topic of the leaderboard of a category
+	categoryTopicPrefix = "category:"
+	// tagTopicPrefix prefixes the topic of the leaderboard of a tag
+	tagTopicPrefix = "tag:"
+	// videoTopicPrefix prefixes the topic of the live counters of a video
+	videoTopicPrefix = "video:"
+	// creatorTopicPrefix prefixes the topic of the stats of a creator
+	creatorTopicPrefix = "creator:"
+)
+
+// Actions a client can send
+const (
+	SubscribeAction   = "subscribe"
+	UnsubscribeAction = "unsubscribe"
+)
+
+// Types of the replies to client requests
+const (
+	SubscribedReply   = "subscribed"
+	UnsubscribedReply = "unsubscribed"
+	ErrorReply        = "error"
+)
+
+// ErrInvalidTopic is returned for a topic that is not one of the known kinds
+var ErrInvalidTopic = errors.New("invalid topic")
+
+// CategoryTopic returns the topic of the leaderboard of a category
+func CategoryTopic(category string) string {
+	return categoryTopicPrefix + category
+}
+
+// TagTopic returns the topic of the leaderboard of a tag
+func TagTopic(tag string) string {
+	return tagTopicPrefix + tag
+}
+
+// VideoTopic returns the topic of the live counters of a video
+func VideoTopic(videoID uuid.UUID) string {
+	return videoTopicPrefix + videoID.String()
+}
+
+// CreatorTopic returns the topic of the stats of a creator
+func CreatorTopic(creatorID uuid.UUID) string {
+	return creatorTopicPrefix + creatorID.String()
+}
+
+// NormalizeTopic checks that a topic is the trending topic, a category, a tag or a video or creator ID and returns
+// it in the form messages are published under: category and tag names trimmed and lowercased, IDs canonical
+func NormalizeTopic(topic string) (string, error) {
+	topic = strings.TrimSpace(topic)
+	switch {
+	case strings.EqualFold(topic, TrendingTopic):
+		return TrendingTopic, nil
+	case hasPrefixFold(topic, categoryTopicPrefix):
+		if name := normalizeName(topic[len(categoryTopicPrefix):]); name != "" {
+			return CategoryTopic(name), nil
+		}
+	case hasPrefixFold(topic, tagTopicPrefix):
+		if name := normalizeName(topic[len(tagTopicPrefix):]); name != "" {
+			return TagTopic(name), nil
+		}
+	case hasPrefixFold(topic, videoTopicPrefix):
+		if id, err := uuid.Parse(strings.TrimSpace(topic[len(videoTopicPrefix):])); err == nil {
+			return VideoTopic(id), nil
+		}
+	case hasPrefixFold(topic, creatorTopicPrefix):
+		if id, err := uuid.Parse(strings.TrimSpace(topic[len(creatorTopicPrefix):])); err == nil {
+			return CreatorTopic(id), nil
+		}
+	}
+	return "", ErrInvalidTopic
+}
+
+// hasPrefixFold reports whether topic begins with prefix, ignoring case
+func hasPrefixFold(topic, prefix string) bool {
+	return len(topic) >= len(prefix) && strings.EqualFold(topic[:len(prefix)], prefix)
+}
+
+// normalizeName returns a category or tag name the way tags are stored
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Request is a message sent by a client to change its subscriptions
+type Request struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+// Reply acknowledges a client request
+type Reply struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic,omitempty"`
+	Error string `json:"error,omitempty"`
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -45,7 +47,7 @@ func main() {
 	}
 	log.Println("Database connection established successfully")
 	// Migrate database schema
-	if err := db.AutoMigrate(&models.Video{}, &models.User{}, &models.Interaction{}); err != nil {
+	if err := db.AutoMigrate(&models.Video{}, &models.User{}, &models.Interaction{}, &models.LeaderboardSnapshot{}, &models.RankingOverride{}, &models.Tag{}, &models.Experiment{}, &models.ExperimentArm{}, &models.DeadLetter{}, &models.OutboxEvent{}, &models.InteractionLog{}, &models.ProcessedEvent{}, &models.SeedMarker{}); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 		return
 	}
@@ -54,21 +56,107 @@ func main() {
 	videoRepo := repositories.NewVideoRepository(db)
 	userRepo := repositories.NewUserRepository(db)
 	interactionRepo := repositories.NewInteractionRepository(db)
+	snapshotRepo := repositories.NewSnapshotRepository(db)
+	overrideRepo := repositories.NewOverrideRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	experimentRepo := repositories.NewExperimentRepository(db)
+	deadLetterRepo := repositories.NewDeadLetterRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	interactionLogRepo := repositories.NewInteractionLogRepository(db)
+	if err := interactionLogRepo.SeedFromInteractions(); err != nil {
+		log.Fatalf("Failed to seed interaction log: %v", err)
+		return
+	}
+
+	// Initialize scoring strategy
+	scorer, err := services.NewScorer(os.Getenv("SCORING_STRATEGY"))
+	if err != nil {
+		log.Fatalf("Error initializing scorer: %v", err)
+		return
+	}
+	log.Printf("Using %s scoring strategy", scorer.Name())
 
 	// Initialize services
-	videoService := services.NewVideoService(videoRepo, redis)
+	overrideService := services.NewOverrideService(overrideRepo, redis, envDuration("OVERRIDE_CACHE_TTL", 10*time.Second))
+	videoService := services.NewVideoService(videoRepo, tagRepo, redis, scorer, overrideService)
 	userService := services.NewUserService(userRepo)
 	interactionService := services.NewInteractionService(interactionRepo)
+	hotRankingService := services.NewHotRankingService(videoRepo, redis, scorer,
+		envFloat("HOT_GRAVITY", 1.8), envDuration("HOT_ACTIVE_WINDOW", 72*time.Hour))
+	windowRankingService := services.NewWindowRankingService(redis, scorer, envDuration("TRENDING_WINDOW_CACHE_TTL", 15*time.Second))
+	risingService := services.NewRisingService(redis, scorer, overrideService, envDuration("RISING_RECENT_WINDOW", 10*time.Minute),
+		envDuration("RISING_BASELINE_WINDOW", 2*time.Hour), envDuration("RISING_CACHE_TTL", 5*time.Second))
+	snapshotService := services.NewSnapshotService(snapshotRepo, redis, overrideService, envInt("SNAPSHOT_TOP_N", 100),
+		envDuration("SNAPSHOT_RETENTION", 90*24*time.Hour))
+	creatorService := services.NewCreatorService(userRepo, videoRepo, redis)
+	explainService := services.NewExplainService(videoService, hotRankingService, overrideService, redis)
+	experimentService := services.NewExperimentService(experimentRepo, videoRepo, redis, overrideService, envDuration("EXPERIMENT_CACHE_TTL", 10*time.Second))
+	idempotencyService := services.NewIdempotencyService(redis, envDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		envDuration("IDEMPOTENCY_PENDING_TTL", 30*time.Second))
+	rebuildService := services.NewRebuildService(videoRepo, redis, envDuration("REBUILD_LOCK_TTL", 10*time.Minute))
+	replayService := services.NewReplayService(interactionLogRepo, videoRepo, rebuildService, scorer)
+	reconcileService := services.NewReconcileService(videoRepo, interactionRepo, interactionLogRepo, deadLetterRepo, outboxRepo, videoService, redis,
+		envDuration("RECONCILE_SETTLE", 5*time.Minute), envDuration("RECONCILE_LOCK_TTL", 30*time.Minute))
+	trendingService := services.NewTrendingService(videoRepo, redis, hotRankingService, windowRankingService, risingService, experimentService, overrideService)
+
+	// Restore the leaderboard if Redis lost it; another instance may already be doing so
+	if result, err := rebuildService.Rebuild(context.Background(), false); errors.Is(err, services.ErrLockHeld) {
+		log.Println("Leaderboard rebuild is running on another instance")
+	} else if err != nil {
+		log.Printf("Error rebuilding leaderboard: %v", err)
+	} else if result.Rebuilt {
+		log.Printf("Rebuilt leaderboard with %d videos", result.Videos)
+	}
+
+	// Background jobs run until the application context is cancelled
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+	go hotRankingService.Run(appCtx, envDuration("HOT_RECOMPUTE_INTERVAL", time.Minute))
+	go snapshotService.Run(appCtx, envDuration("SNAPSHOT_INTERVAL", 15*time.Minute))
+	go reconcileService.Run(appCtx, envDuration("RECONCILE_INTERVAL", time.Hour))
+	go videoService.RunOverrideExpiry(appCtx, envDuration("OVERRIDE_EXPIRY_INTERVAL", 10*time.Second))
+	go experimentService.WatchStops(appCtx)
 
 	// Start queue consumer
-	queue := make(chan models.InteractionEvent, 100)
-	queueServices := services.NewQueueServices(videoService, queue)
-	go services.QueueConsumer(queue, queueServices)
+	var queue services.EventQueue
+	queueCapacity := envInt("QUEUE_CAPACITY", 100000)
+	switch backend := os.Getenv("QUEUE_BACKEND"); backend {
+	case "", services.RedisQueueBackend:
+		queue = services.NewRedisStreamQueue(redis, "interactions:stream", "ranking", consumerName(),
+			int64(envInt("QUEUE_MAX_LEN", 1000000)), envDuration("QUEUE_CLAIM_IDLE", time.Minute))
+	case services.MemoryQueueBackend:
+		queueCapacity = envInt("QUEUE_SIZE", 100)
+		queue = services.NewMemoryQueue(queueCapacity)
+	default:
+		log.Fatalf("Unknown queue backend %q", backend)
+		return
+	}
+	deadLetterService := services.NewDeadLetterService(deadLetterRepo, queue)
+	retryPolicy := services.NewRetryPolicy(envInt("QUEUE_MAX_ATTEMPTS", 5),
+		envDuration("QUEUE_RETRY_BASE_DELAY", 100*time.Millisecond), envDuration("QUEUE_RETRY_MAX_DELAY", 5*time.Second))
+	queueServices := services.NewQueueServices(videoService, queue, retryPolicy, deadLetterService,
+		windowRankingService, risingService, experimentService)
+	queueConsumer := services.NewQueueConsumer(queueServices, envInt("QUEUE_WORKERS", 4),
+		envDuration("QUEUE_FLUSH_INTERVAL", 500*time.Millisecond), envInt("QUEUE_BATCH_SIZE", 500))
+	go queueConsumer.Run(appCtx)
+	outboxRelay := services.NewOutboxRelay(outboxRepo, queue, envInt("OUTBOX_BATCH_SIZE", 500),
+		envDuration("OUTBOX_CLAIM_TTL", 30*time.Second), envDuration("OUTBOX_RETENTION", 24*time.Hour),
+		envDuration("PROCESSED_EVENT_RETENTION", 7*24*time.Hour))
+	go outboxRelay.Run(appCtx, envDuration("OUTBOX_RELAY_INTERVAL", 200*time.Millisecond))
+	backpressure := services.NewBackpressure(queue, outboxRelay, int64(queueCapacity), envFloat("QUEUE_VIEW_SHARE", 0.8),
+		envDuration("QUEUE_ENQUEUE_TIMEOUT", 2*time.Second), envDuration("QUEUE_RETRY_AFTER", time.Second))
 
 	// Initialize handlers
 	videoHandler := handlers.NewVideoHandler(videoService)
 	userHandler := handlers.NewUserHandler(userService)
-	interactionHandler := handlers.NewInteractionHandler(interactionService, videoService, userService, queueServices)
+	interactionHandler := handlers.NewInteractionHandler(interactionService, videoService, userService, backpressure, idempotencyService)
+	trendingHandler := handlers.NewTrendingHandler(trendingService, snapshotService, explainService)
+	overrideHandler := handlers.NewOverrideHandler(overrideService, videoService, experimentService)
+	creatorHandler := handlers.NewCreatorHandler(creatorService, userService)
+	experimentHandler := handlers.NewExperimentHandler(experimentService)
+	deadLetterHandler := handlers.NewDeadLetterHandler(deadLetterService)
+	queueHandler := handlers.NewQueueHandler(backpressure)
+	maintenanceHandler := handlers.NewMaintenanceHandler(replayService, rebuildService, reconcileService, videoService)
 
 	// Initialize router
 	r := mux.NewRouter()
@@ -77,6 +165,13 @@ func main() {
 	videoHandler.RegisterRoutes(r)
 	userHandler.RegisterRoutes(r)
 	interactionHandler.RegisterRoutes(r)
+	trendingHandler.RegisterRoutes(r)
+	overrideHandler.RegisterRoutes(r)
+	creatorHandler.RegisterRoutes(r)
+	experimentHandler.RegisterRoutes(r)
+	deadLetterHandler.RegisterRoutes(r)
+	queueHandler.RegisterRoutes(r)
+	maintenanceHandler.RegisterRoutes(r)
 
 	// Swagger documentation
 	r.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
@@ -90,7 +185,7 @@ func main() {
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key"},
 		AllowCredentials: true,
 	})
 
@@ -117,18 +212,61 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	r.HandleFunc("/ws", ws.WsHandler).Methods("GET")
+	r.HandleFunc("/ws", ws.NewWsHandler(experimentHandler.WsGroup)).Methods("GET")
 	<-sigChan
 	log.Println("Shutting down server...")
 
 	// Create shutdown context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
 	defer cancel()
 
-	// Shutdown server
+	// Shutdown server and background jobs first so no new events are enqueued while the queue drains.
+	// Events still in the outbox are relayed on the next start.
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server shutdown error: %v", err)
 	}
+	stopApp()
+	report := queueConsumer.Shutdown(ctx)
+	log.Printf("Interaction queue drained: %d events processed, %d spilled", report.Processed, report.Spilled)
 
 	log.Println("Server stopped successfully")
 }
+
+// consumerName identifies this process within the queue consumer group
+func consumerName() string {
+	if name := os.Getenv("QUEUE_CONSUMER_NAME"); name != "" {
+		return name
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "video-api"
+	}
+	return hostname
+}
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// envFloat reads a float environment variable, falling back to def when unset or invalid
+func envFloat(key string, def float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envDuration reads a duration environment variable such as "30s", falling back to def when unset or invalid
+func envDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}